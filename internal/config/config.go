@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"storage-api/internal/utils"
 	"strings"
 
@@ -14,54 +15,388 @@ import (
 
 // ValidationRule represents a file validation rule
 type ValidationRule struct {
-	Name       string   `yaml:"name"`
-	Extensions []string `yaml:"extensions,omitempty"`
-	Patterns   []string `yaml:"patterns,omitempty"`
-	MimeTypes  []string `yaml:"mime_types,omitempty"`
-	MaxSize    string   `yaml:"max_size,omitempty"`
-	Allow      bool     `yaml:"allow"`
+	Name         string   `yaml:"name"`
+	Extensions   []string `yaml:"extensions,omitempty"`
+	Patterns     []string `yaml:"patterns,omitempty"`
+	MimeTypes    []string `yaml:"mime_types,omitempty"`
+	MaxSize      string   `yaml:"max_size,omitempty"`
+	Allow        bool     `yaml:"allow"`
+	KeepOriginal *bool    `yaml:"keep_original,omitempty"`
+	Tag          string   `yaml:"tag,omitempty"`
+	Priority     int      `yaml:"priority,omitempty"`
+	MinWidth     int      `yaml:"min_width,omitempty"`
+	MinHeight    int      `yaml:"min_height,omitempty"`
+	MaxWidth     int      `yaml:"max_width,omitempty"`
+	MaxHeight    int      `yaml:"max_height,omitempty"`
+}
+
+// HasImageDimensionLimits reports whether the rule limits the width or height of images
+func (r ValidationRule) HasImageDimensionLimits() bool {
+	return r.MinWidth > 0 || r.MinHeight > 0 || r.MaxWidth > 0 || r.MaxHeight > 0
+}
+
+// ArchiveLimitsConfig holds limits applied to uploaded ZIP-based archives
+type ArchiveLimitsConfig struct {
+	Enabled             bool   `yaml:"enabled"`
+	MaxUncompressedSize string `yaml:"max_uncompressed_size"`
+	MaxEntries          int    `yaml:"max_entries"`
+	MaxCompressionRatio int    `yaml:"max_compression_ratio"`
 }
 
 // FileValidationConfig holds file validation settings
 type FileValidationConfig struct {
-	DefaultMaxSize       string           `yaml:"default_max_size"`
-	DefaultAction        string           `yaml:"default_action"`
-	StrictMimeValidation bool             `yaml:"strict_mime_validation"`
-	Rules                []ValidationRule `yaml:"rules"`
+	DefaultMaxSize        string              `yaml:"default_max_size"`
+	DefaultAction         string              `yaml:"default_action"`
+	StrictMimeValidation  bool                `yaml:"strict_mime_validation"`
+	DeriveMimeTypes       bool                `yaml:"derive_mime_types"`
+	StoreDetectedMimeType bool                `yaml:"store_detected_mime_type"`
+	StrictExtensionMatch  bool                `yaml:"strict_extension_match"`
+	BlockNoExtension      bool                `yaml:"block_no_extension"`
+	MimeSniffSize         string              `yaml:"mime_sniff_size"`
+	RejectPolyglots       bool                `yaml:"reject_polyglots"`
+	ArchiveLimits         ArchiveLimitsConfig `yaml:"archive_limits"`
+	Rules                 []ValidationRule    `yaml:"rules"`
 }
 
 // UploadConfig holds upload settings
 type UploadConfig struct {
-	MaxFiles     int    `yaml:"max_files"`
-	MaxTotalSize string `yaml:"max_total_size"`
+	MaxFiles            int                 `yaml:"max_files"`
+	MaxTotalSize        string              `yaml:"max_total_size"`
+	MaxConcurrentBytes  string              `yaml:"max_concurrent_bytes"`
+	MultipartMemory     string              `yaml:"multipart_memory"`
+	RetryAfterSeconds   int                 `yaml:"retry_after_seconds"`
+	AggregateRejections bool                `yaml:"aggregate_rejections"`
+	LenientValidation   bool                `yaml:"lenient_validation"`
+	DefaultStatus       string              `yaml:"default_status"`
+	BatchDuplicates     string              `yaml:"batch_duplicates"`
+	PerIP               UploadIPLimitConfig `yaml:"per_ip"`
+	LowDisk             LowDiskConfig       `yaml:"low_disk"`
+	Quota               QuotaConfig         `yaml:"quota"`
+}
+
+// QuotaConfig holds soft storage quota settings reported on successful uploads
+type QuotaConfig struct {
+	Limit       string `yaml:"limit"`
+	WarnPercent int    `yaml:"warn_percent"`
+}
+
+// LowDiskConfig holds free disk space thresholds below which uploads are rejected
+type LowDiskConfig struct {
+	SoftThreshold     string `yaml:"soft_threshold"`
+	HardThreshold     string `yaml:"hard_threshold"`
+	SoftMaxUploadSize string `yaml:"soft_max_upload_size"`
+}
+
+// UploadIPLimitConfig holds per-client-IP upload limit settings
+type UploadIPLimitConfig struct {
+	MaxConcurrent int `yaml:"max_concurrent"`
+	MaxPerMinute  int `yaml:"max_per_minute"`
 }
 
 // FileNamingConfig holds file naming strategy settings
 type FileNamingConfig struct {
 	Strategy          string `yaml:"strategy"`
 	PreserveExtension bool   `yaml:"preserve_extension"`
+	Transliterate     bool   `yaml:"transliterate"`
+	NormalizeUnicode  bool   `yaml:"normalize_unicode"`
 }
 
 // StorageOrganizationConfig holds file organization settings
 type StorageOrganizationConfig struct {
-	Pattern     string           `yaml:"pattern"`
-	DateFormat  string           `yaml:"date_format"`
-	IncludeTime bool             `yaml:"include_time"`
-	Naming      FileNamingConfig `yaml:"naming"`
+	Pattern          string           `yaml:"pattern"`
+	DateFormat       string           `yaml:"date_format"`
+	IncludeTime      bool             `yaml:"include_time"`
+	FileTypeFromRule bool             `yaml:"file_type_from_rule"`
+	TypeFallback     string           `yaml:"type_fallback"`
+	Naming           FileNamingConfig `yaml:"naming"`
 }
 
-// LocalStorageConfig holds local storage settings
-type LocalStorageConfig struct {
+// StorageBackendConfig holds settings for an additional named storage backend
+type StorageBackendConfig struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
 	UploadDir  string `yaml:"upload_dir"`
 	CreateDirs bool   `yaml:"create_dirs"`
 }
 
+// LocalStorageConfig holds local storage settings
+type LocalStorageConfig struct {
+	UploadDir        string                 `yaml:"upload_dir"`
+	CreateDirs       bool                   `yaml:"create_dirs"`
+	PruneEmptyDirs   bool                   `yaml:"prune_empty_dirs"`
+	Backends         []StorageBackendConfig `yaml:"backends,omitempty"`
+	CategoryBackends map[string]string      `yaml:"category_backends,omitempty"`
+}
+
+// PreviewConfig holds document preview settings
+type PreviewConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Renderer  string `yaml:"renderer"`
+	OutputDir string `yaml:"output_dir"`
+	Width     int    `yaml:"width"`
+	OnUpload  bool   `yaml:"on_upload"`
+}
+
+// PerceptualHashConfig holds near-duplicate image detection settings
+type PerceptualHashConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Threshold int  `yaml:"threshold"`
+}
+
+// HEICConversionConfig holds HEIC to JPEG transcoding settings
+type HEICConversionConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	Converter    string `yaml:"converter"`
+	Quality      int    `yaml:"quality"`
+	KeepOriginal bool   `yaml:"keep_original"`
+}
+
+// ThumbnailConfig holds image thumbnail settings
+type ThumbnailConfig struct {
+	OutputDir           string `yaml:"output_dir"`
+	Size                int    `yaml:"size"`
+	Quality             int    `yaml:"quality"`
+	MaxBatch            int    `yaml:"max_batch"`
+	RegenerateBatchSize int    `yaml:"regenerate_batch_size"`
+}
+
+// RehashConfig holds settings for the background re-hashing migration
+type RehashConfig struct {
+	BatchSize         int `yaml:"batch_size"`
+	MaxFilesPerSecond int `yaml:"max_files_per_second"`
+}
+
+// HashingConfig holds content hashing settings
+type HashingConfig struct {
+	Algorithm string       `yaml:"algorithm"`
+	Rehash    RehashConfig `yaml:"rehash"`
+}
+
+// GzipVariantConfig holds settings for precompressed gzip download variants
+type GzipVariantConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	MinSize           string `yaml:"min_size"`
+	MinSavingsPercent int    `yaml:"min_savings_percent"`
+}
+
+// HotlinkConfig holds download referer and origin checking settings
+type HotlinkConfig struct {
+	AllowedOrigins    []string `yaml:"allowed_origins"`
+	AllowEmptyReferer bool     `yaml:"allow_empty_referer"`
+}
+
+// DownloadConfig holds file download settings
+type DownloadConfig struct {
+	StrongETag               bool              `yaml:"strong_etag"`
+	DigestHeaders            bool              `yaml:"digest_headers"`
+	VerifyHash               bool              `yaml:"verify_hash"`
+	MaxConcurrentPerFile     int               `yaml:"max_concurrent_per_file"`
+	MaxConcurrentTotal       int               `yaml:"max_concurrent_total"`
+	RetryAfterSeconds        int               `yaml:"retry_after_seconds"`
+	ContentSecurityPolicy    string            `yaml:"content_security_policy"`
+	AllowInlineActiveContent bool              `yaml:"allow_inline_active_content"`
+	FallbackCharset          string            `yaml:"fallback_charset"`
+	GzipVariants             GzipVariantConfig `yaml:"gzip_variants"`
+	FilenameTemplate         string            `yaml:"filename_template"`
+	FilenameEncoding         string            `yaml:"filename_encoding"`
+	HotlinkProtection        HotlinkConfig     `yaml:"hotlink_protection"`
+}
+
+// ScanningConfig holds asynchronous virus scanning settings
+type ScanningConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Scanner        string `yaml:"scanner"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
+// SearchConfig holds file search settings
+type SearchConfig struct {
+	DefaultSortBy    string `yaml:"default_sort_by"`
+	DefaultSortOrder string `yaml:"default_sort_order"`
+	DefaultLimit     int    `yaml:"default_limit"`
+	MaxLimit         int    `yaml:"max_limit"`
+	ExportBatchSize  int    `yaml:"export_batch_size"`
+}
+
+// GetExportBatchSize returns how many files an export reads per query
+func (c *SearchConfig) GetExportBatchSize() int {
+	if c.ExportBatchSize <= 0 {
+		return 500
+	}
+	return c.ExportBatchSize
+}
+
+// GetMaxLimit returns the largest page size a search may request
+func (c *SearchConfig) GetMaxLimit() int {
+	if c.MaxLimit <= 0 {
+		return 100
+	}
+	return c.MaxLimit
+}
+
+// GetDefaultLimit returns the page size used when a search doesn't specify one, capped at the maximum
+func (c *SearchConfig) GetDefaultLimit() int {
+	if c.DefaultLimit <= 0 {
+		return min(20, c.GetMaxLimit())
+	}
+	return min(c.DefaultLimit, c.GetMaxLimit())
+}
+
+// FolderConfig holds virtual folder settings
+type FolderConfig struct {
+	AutoCreate    bool `yaml:"auto_create"`
+	MaxDepth      int  `yaml:"max_depth"`
+	MaxNameLength int  `yaml:"max_name_length"`
+	UniqueNames   bool `yaml:"unique_names"`
+}
+
+// URLFetchConfig holds upload-from-URL settings
+type URLFetchConfig struct {
+	Enabled               bool   `yaml:"enabled"`
+	ConnectTimeoutSeconds int    `yaml:"connect_timeout_seconds"`
+	TimeoutSeconds        int    `yaml:"timeout_seconds"`
+	MaxSize               string `yaml:"max_size"`
+	AllowPrivateNetworks  bool   `yaml:"allow_private_networks"`
+}
+
+// TransformationConfig holds settings shared by upload transformations (e.g. HEIC conversion)
+type TransformationConfig struct {
+	KeepOriginal bool `yaml:"keep_original"`
+}
+
+// WriteBatchingConfig holds settings for batching file record inserts
+type WriteBatchingConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	BatchSize       int  `yaml:"batch_size"`
+	FlushIntervalMs int  `yaml:"flush_interval_ms"`
+	QueueSize       int  `yaml:"queue_size"`
+}
+
+// DeduplicationConfig holds content deduplication settings
+type DeduplicationConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// RejectionLogConfig holds settings for recording rejected uploads
+type RejectionLogConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// MetadataConfig holds settings for file metadata responses
+type MetadataConfig struct {
+	ExposeStorageLocation bool   `yaml:"expose_storage_location"`
+	InlineContentMaxSize  string `yaml:"inline_content_max_size"`
+	RecordRequestID       bool   `yaml:"record_request_id"`
+	WeakETag              bool   `yaml:"weak_etag"`
+}
+
+// IntegrityConfig holds stored content verification settings
+type IntegrityConfig struct {
+	AutoMarkCorrupted bool `yaml:"auto_mark_corrupted"`
+	IntervalHours     int  `yaml:"interval_hours"`
+	BatchSize         int  `yaml:"batch_size"`
+	MaxFilesPerSecond int  `yaml:"max_files_per_second"`
+}
+
+// ReindexConfig holds search field reindex settings
+type ReindexConfig struct {
+	BatchSize int `yaml:"batch_size"`
+}
+
+// LinkConfig holds computed file URL settings
+type LinkConfig struct {
+	BaseURL    string `yaml:"base_url"`
+	Signing    bool   `yaml:"signing"`
+	TTLSeconds int    `yaml:"ttl_seconds"`
+}
+
+// DeletionConfig holds stored content removal settings
+type DeletionConfig struct {
+	MaxRetries        int `yaml:"max_retries"`
+	RetryDelayMs      int `yaml:"retry_delay_ms"`
+	GCIntervalMinutes int `yaml:"gc_interval_minutes"`
+}
+
+// ContentIndexConfig holds document text extraction and full-text search settings
+type ContentIndexConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	PDFExtractor string `yaml:"pdf_extractor"`
+	MaxTextSize  int64  `yaml:"max_text_size"`
+	Language     string `yaml:"language"`
+}
+
+// DatabaseStartupConfig holds database connection settings applied at startup
+type DatabaseStartupConfig struct {
+	MaxAttempts        int  `yaml:"max_attempts"`
+	RetryDelayMs       int  `yaml:"retry_delay_ms"`
+	MaxRetryDelayMs    int  `yaml:"max_retry_delay_ms"`
+	MaxDurationSeconds int  `yaml:"max_duration_seconds"`
+	StartDegraded      bool `yaml:"start_degraded"`
+}
+
+// ClientConfig holds client address resolution and uploader recording settings
+type ClientConfig struct {
+	RecordUploader bool     `yaml:"record_uploader"`
+	ProxyHeader    string   `yaml:"proxy_header"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// MaintenanceConfig holds the upload maintenance mode settings applied at startup
+type MaintenanceConfig struct {
+	PauseUploads      bool   `yaml:"pause_uploads"`
+	Message           string `yaml:"message"`
+	RetryAfterSeconds int    `yaml:"retry_after_seconds"`
+}
+
+// IDConfig holds record ID generation settings
+type IDConfig struct {
+	Strategy string `yaml:"strategy"`
+}
+
+// TaggingConfig holds file tag settings
+type TaggingConfig struct {
+	AutoTagByCategory bool `yaml:"auto_tag_by_category"`
+	MaxTagsPerFile    int  `yaml:"max_tags_per_file"`
+	MaxTagLength      int  `yaml:"max_tag_length"`
+}
+
+// ReferencesConfig holds settings for external references to files
+type ReferencesConfig struct {
+	ProtectDeletion bool `yaml:"protect_deletion"`
+}
+
 // StorageConfig holds the complete storage configuration
 type StorageConfig struct {
-	Validation   FileValidationConfig      `yaml:"validation"`
-	Upload       UploadConfig              `yaml:"upload"`
-	Organization StorageOrganizationConfig `yaml:"organization"`
-	Storage      LocalStorageConfig        `yaml:"storage"`
+	Validation      FileValidationConfig      `yaml:"validation"`
+	Upload          UploadConfig              `yaml:"upload"`
+	Organization    StorageOrganizationConfig `yaml:"organization"`
+	Storage         LocalStorageConfig        `yaml:"storage"`
+	Preview         PreviewConfig             `yaml:"preview"`
+	PerceptualHash  PerceptualHashConfig      `yaml:"perceptual_hash"`
+	HEICConversion  HEICConversionConfig      `yaml:"heic_conversion"`
+	Thumbnails      ThumbnailConfig           `yaml:"thumbnails"`
+	Hashing         HashingConfig             `yaml:"hashing"`
+	Download        DownloadConfig            `yaml:"download"`
+	Scanning        ScanningConfig            `yaml:"scanning"`
+	Search          SearchConfig              `yaml:"search"`
+	Folders         FolderConfig              `yaml:"folders"`
+	URLFetch        URLFetchConfig            `yaml:"url_fetch"`
+	Transformations TransformationConfig      `yaml:"transformations"`
+	WriteBatching   WriteBatchingConfig       `yaml:"write_batching"`
+	Deduplication   DeduplicationConfig       `yaml:"deduplication"`
+	RejectionLog    RejectionLogConfig        `yaml:"rejection_log"`
+	Metadata        MetadataConfig            `yaml:"metadata"`
+	Integrity       IntegrityConfig           `yaml:"integrity"`
+	Reindex         ReindexConfig             `yaml:"reindex"`
+	Links           LinkConfig                `yaml:"links"`
+	Deletion        DeletionConfig            `yaml:"deletion"`
+	ContentIndex    ContentIndexConfig        `yaml:"content_index"`
+	DatabaseStartup DatabaseStartupConfig     `yaml:"database_startup"`
+	Tagging         TaggingConfig             `yaml:"tagging"`
+	References      ReferencesConfig          `yaml:"references"`
+	IDs             IDConfig                  `yaml:"ids"`
+	Maintenance     MaintenanceConfig         `yaml:"maintenance"`
+	Clients         ClientConfig              `yaml:"clients"`
 }
 
 // MainConfig holds the root configuration
@@ -88,6 +423,90 @@ func (c *FileValidationConfig) IsDefaultActionBlock() bool {
 	return strings.ToLower(c.DefaultAction) == "block"
 }
 
+// NamingStrategies lists the supported stored file naming strategies
+var NamingStrategies = []string{"original", "uuid", "timestamp"}
+
+// IsSupportedNamingStrategy reports whether the stored file naming strategy is known
+func IsSupportedNamingStrategy(strategy string) bool {
+	for _, supported := range NamingStrategies {
+		if strategy == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadStatuses lists the statuses new uploads may start in
+var UploadStatuses = []string{"active", "inactive", "archived"}
+
+// IsSupportedUploadStatus reports whether uploads may start in the status
+func IsSupportedUploadStatus(status string) bool {
+	for _, supported := range UploadStatuses {
+		if status == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDefaultStatus returns the status new uploads start in unless the request asks for another
+func (c UploadConfig) GetDefaultStatus() string {
+	if c.DefaultStatus == "" {
+		return "active"
+	}
+	return c.DefaultStatus
+}
+
+// BatchDuplicateModes lists how files repeated within one upload batch may be handled
+var BatchDuplicateModes = []string{"reject", "merge"}
+
+// GetBatchDuplicates returns how a file repeated within one upload batch is handled: 'reject'
+// fails the repeat, 'merge' points it at the record stored for the first copy
+func (c UploadConfig) GetBatchDuplicates() string {
+	if c.BatchDuplicates == "" {
+		return "reject"
+	}
+	return c.BatchDuplicates
+}
+
+// NormalizeExtensions rewrites the extensions listed by rules into their canonical form, so ".JPG"
+// in the configuration matches and reports the same way as "jpg"
+func (c *FileValidationConfig) NormalizeExtensions() {
+	for i := range c.Rules {
+		for j, ext := range c.Rules[i].Extensions {
+			c.Rules[i].Extensions[j] = utils.NormalizeExtension(ext)
+		}
+	}
+}
+
+// ValidateRules checks rule priorities. Priorities can't be negative, and rules of equal priority
+// sharing an extension are reported since only their definition order decides which applies.
+func (c *FileValidationConfig) ValidateRules() error {
+	owners := make(map[string]string)
+	for _, rule := range c.Rules {
+		if rule.Priority < 0 {
+			return fmt.Errorf("validation rule '%s' has negative priority %d", rule.Name, rule.Priority)
+		}
+		if rule.MinWidth < 0 || rule.MinHeight < 0 || rule.MaxWidth < 0 || rule.MaxHeight < 0 {
+			return fmt.Errorf("validation rule '%s' has a negative image dimension limit", rule.Name)
+		}
+		if (rule.MaxWidth > 0 && rule.MinWidth > rule.MaxWidth) || (rule.MaxHeight > 0 && rule.MinHeight > rule.MaxHeight) {
+			return fmt.Errorf("validation rule '%s' has a minimum image dimension above its maximum", rule.Name)
+		}
+
+		for _, ext := range rule.Extensions {
+			key := fmt.Sprintf("%d/%s", rule.Priority, ext)
+			if owner, ok := owners[key]; ok {
+				log.Printf("Warning: Validation rules '%s' and '%s' both match .%s at priority %d; '%s' applies because it is defined first",
+					owner, rule.Name, ext, rule.Priority, owner)
+				continue
+			}
+			owners[key] = rule.Name
+		}
+	}
+	return nil
+}
+
 // LoadConfig loads the configuration from the specified path
 func LoadConfig() error {
 	// Load .env file if it exists
@@ -109,6 +528,28 @@ func LoadConfig() error {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if strategy := config.Storage.Organization.Naming.Strategy; !IsSupportedNamingStrategy(strategy) {
+		return fmt.Errorf("unsupported file naming strategy '%s' (supported: %s)", strategy, strings.Join(NamingStrategies, ", "))
+	}
+
+	// The fallback becomes a single directory name in stored file paths
+	if fallback := config.Storage.Organization.TypeFallback; fallback == "." || fallback == ".." || strings.ContainsAny(fallback, `/\`) {
+		return fmt.Errorf("invalid organization type fallback '%s': must be a single directory name", fallback)
+	}
+
+	if status := config.Storage.Upload.GetDefaultStatus(); !IsSupportedUploadStatus(status) {
+		return fmt.Errorf("unsupported default upload status '%s' (supported: %s)", status, strings.Join(UploadStatuses, ", "))
+	}
+
+	if mode := config.Storage.Upload.GetBatchDuplicates(); !slices.Contains(BatchDuplicateModes, mode) {
+		return fmt.Errorf("unsupported batch duplicate handling '%s' (supported: %s)", mode, strings.Join(BatchDuplicateModes, ", "))
+	}
+
+	config.Storage.Validation.NormalizeExtensions()
+	if err := config.Storage.Validation.ValidateRules(); err != nil {
+		return fmt.Errorf("invalid validation rules: %w", err)
+	}
+
 	// Store config globally
 	Config = config
 
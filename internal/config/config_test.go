@@ -0,0 +1,68 @@
+package config
+
+import "testing"
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []ValidationRule
+		wantErr bool
+	}{
+		{
+			name:  "no rules",
+			rules: nil,
+		},
+		{
+			name: "distinct priorities",
+			rules: []ValidationRule{
+				{Name: "images", Extensions: []string{"jpg", "png"}, Priority: 10},
+				{Name: "large-images", Extensions: []string{"jpg"}, Priority: 20},
+			},
+		},
+		{
+			// Equal priorities on a shared extension are only warned about; definition order decides
+			name: "equal priorities on a shared extension",
+			rules: []ValidationRule{
+				{Name: "photos", Extensions: []string{"jpg"}, Priority: 5},
+				{Name: "scans", Extensions: []string{"jpg"}, Priority: 5},
+			},
+		},
+		{
+			name: "negative priority",
+			rules: []ValidationRule{
+				{Name: "images", Extensions: []string{"jpg"}, Priority: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative dimension",
+			rules: []ValidationRule{
+				{Name: "images", Extensions: []string{"jpg"}, MinWidth: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "minimum above maximum",
+			rules: []ValidationRule{
+				{Name: "images", Extensions: []string{"jpg"}, MinHeight: 800, MaxHeight: 600},
+			},
+			wantErr: true,
+		},
+		{
+			name: "minimum without maximum",
+			rules: []ValidationRule{
+				{Name: "images", Extensions: []string{"jpg"}, MinWidth: 800},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := FileValidationConfig{Rules: tt.rules}
+			err := validation.ValidateRules()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRules() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
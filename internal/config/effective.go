@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kerimovok/go-pkg-utils/config"
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secret values in the effective configuration
+const redactedValue = "[REDACTED]"
+
+// secretKeyFragments mark configuration keys whose values are secret
+var secretKeyFragments = []string{"password", "secret", "token", "credential", "api_key", "private_key", "signing_key"}
+
+// environmentVariables lists the environment variables the service reads and whether each holds a secret
+var environmentVariables = []struct {
+	name   string
+	secret bool
+}{
+	{"GO_ENV", false},
+	{"PORT", false},
+	{"DB_HOST", false},
+	{"DB_PORT", false},
+	{"DB_NAME", false},
+	{"DB_USER", false},
+	{"DB_PASS", true},
+	{"ADMIN_API_KEY", true},
+	{"URL_SIGNING_KEY", true},
+}
+
+// GetEffectiveConfig returns the configuration in effect, keyed as in config/storage.yaml, together
+// with the environment variables the service reads. Secret values are redacted; unset secrets stay
+// empty so operators can still tell whether they are configured.
+func GetEffectiveConfig() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	var effective map[string]interface{}
+	if err := yaml.Unmarshal(data, &effective); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+	redactSecrets(effective)
+
+	environment := make(map[string]string, len(environmentVariables))
+	for _, variable := range environmentVariables {
+		value := config.GetEnv(variable.name)
+		if variable.secret && value != "" {
+			value = redactedValue
+		}
+		environment[variable.name] = value
+	}
+	effective["environment"] = environment
+
+	return effective, nil
+}
+
+// redactSecrets replaces the values of secret keys and the passwords of URLs in place
+func redactSecrets(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			if text, ok := nested.(string); ok {
+				if isSecretKey(key) && text != "" {
+					value[key] = redactedValue
+				} else {
+					value[key] = redactURLPassword(text)
+				}
+				continue
+			}
+			redactSecrets(nested)
+		}
+	case []interface{}:
+		for i, nested := range value {
+			if text, ok := nested.(string); ok {
+				value[i] = redactURLPassword(text)
+				continue
+			}
+			redactSecrets(nested)
+		}
+	}
+}
+
+// isSecretKey reports whether a configuration key names a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range secretKeyFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURLPassword masks the password of a URL carrying credentials and returns other values unchanged
+func redactURLPassword(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.User == nil {
+		return value
+	}
+	return parsed.Redacted()
+}
@@ -0,0 +1,69 @@
+package constants
+
+import (
+	"sort"
+	"strings"
+)
+
+// CanonicalExtensions maps detected content types to the extensions that may legitimately carry them.
+// Only content types that utils.DetectContentType identifies unambiguously are listed; generic types
+// such as text/plain or application/octet-stream are intentionally absent.
+var CanonicalExtensions = map[string][]string{
+	"image/png":                    {"png"},
+	"image/jpeg":                   {"jpg", "jpeg", "jpe", "jfif"},
+	"image/gif":                    {"gif"},
+	"image/webp":                   {"webp"},
+	"image/bmp":                    {"bmp"},
+	"image/x-icon":                 {"ico"},
+	"application/pdf":              {"pdf"},
+	"application/zip":              {"zip", "docx", "xlsx", "pptx", "odt", "ods", "odp", "jar", "apk", "epub"},
+	"application/x-gzip":           {"gz", "tgz"},
+	"application/x-rar-compressed": {"rar"},
+	"application/wasm":             {"wasm"},
+	"application/x-tar":            {"tar"},
+	"application/x-iso9660-image":  {"iso"},
+	"application/dicom":            {"dcm"},
+	"audio/mpeg":                   {"mp3"},
+	"audio/wave":                   {"wav"},
+	"audio/aiff":                   {"aif", "aiff"},
+	"audio/basic":                  {"au", "snd"},
+	"audio/midi":                   {"mid", "midi"},
+	"application/ogg":              {"ogg", "oga", "ogv", "opus"},
+	"video/avi":                    {"avi"},
+	"video/mp4":                    {"mp4", "m4v", "m4a", "mov"},
+	"video/webm":                   {"webm", "mkv"},
+	"font/woff":                    {"woff"},
+	"font/woff2":                   {"woff2"},
+	"font/ttf":                     {"ttf"},
+	"font/otf":                     {"otf"},
+}
+
+// GetCanonicalExtensions returns the extensions expected for a detected content type.
+// The second return value is false when the content type has no canonical mapping.
+func GetCanonicalExtensions(contentType string) ([]string, bool) {
+	// Strip parameters such as "; charset=utf-8"
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+
+	extensions, ok := CanonicalExtensions[strings.TrimSpace(strings.ToLower(contentType))]
+	return extensions, ok
+}
+
+// GetMimeTypesForExtension returns the detected content types that may legitimately carry an extension.
+// It is the reverse of CanonicalExtensions and returns nil for extensions with no known content type.
+func GetMimeTypesForExtension(ext string) []string {
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+
+	var mimeTypes []string
+	for contentType, extensions := range CanonicalExtensions {
+		for _, extension := range extensions {
+			if extension == ext {
+				mimeTypes = append(mimeTypes, contentType)
+				break
+			}
+		}
+	}
+	sort.Strings(mimeTypes)
+	return mimeTypes
+}
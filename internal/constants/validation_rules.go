@@ -2,8 +2,8 @@ package constants
 
 import (
 	"fmt"
-	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"storage-api/internal/config"
@@ -25,27 +25,52 @@ type ValidationResult struct {
 	MaxSize     int64
 	RuleName    string
 	Reason      string
+	Code        string
 	MatchedRule *config.ValidationRule
 }
 
+// ExtensionDetail describes the validation applied to files with a given extension
+type ExtensionDetail struct {
+	Extension string   `json:"extension"`
+	Rule      string   `json:"rule"`
+	Allowed   bool     `json:"allowed"`
+	MaxSize   int64    `json:"max_size"`
+	MimeTypes []string `json:"mime_types,omitempty"`
+}
+
 // ValidationEngine handles file validation using rules
 type ValidationEngine struct {
 	config config.FileValidationConfig
 }
 
-// NewValidationEngine creates a new validation engine
-func NewValidationEngine(config config.FileValidationConfig) *ValidationEngine {
+// NewValidationEngine creates a new validation engine. Rules are evaluated by descending
+// priority; rules with equal priority keep their definition order.
+func NewValidationEngine(validationConfig config.FileValidationConfig) *ValidationEngine {
+	rules := make([]config.ValidationRule, len(validationConfig.Rules))
+	copy(rules, validationConfig.Rules)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+	validationConfig.Rules = rules
+
 	return &ValidationEngine{
-		config: config,
+		config: validationConfig,
 	}
 }
 
 // ValidateFile validates a file based on the configured rules
 func (e *ValidationEngine) ValidateFile(filename, mimeType string, fileSize int64) *ValidationResult {
 	// Get file extension
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != "" {
-		ext = strings.TrimPrefix(ext, ".")
+	ext := utils.GetFileExtension(filename)
+
+	// Extensionless files are a common vector for disguised executables
+	if ext == "" && e.config.BlockNoExtension {
+		return &ValidationResult{
+			IsAllowed: false,
+			RuleName:  "No Extension",
+			Reason:    "Files without an extension are not allowed",
+			Code:      "NO_EXTENSION",
+		}
 	}
 
 	// Try to match rules
@@ -152,18 +177,21 @@ func (e *ValidationEngine) applyRule(rule config.ValidationRule, fileSize int64,
 			return result
 		}
 
+		result.MaxSize = maxSize
 		if fileSize > maxSize {
+			result.IsAllowed = false
+			result.Code = "FILE_TOO_LARGE"
 			result.Reason = fmt.Sprintf("File size %s exceeds limit %s set by rule '%s'",
 				FormatFileSize(fileSize), rule.MaxSize, rule.Name)
 			return result
 		}
-
-		result.MaxSize = maxSize
 	} else {
 		// Use default size limit
 		result.MaxSize = e.config.GetDefaultMaxFileSize()
 
 		if fileSize > result.MaxSize {
+			result.IsAllowed = false
+			result.Code = "FILE_TOO_LARGE"
 			result.Reason = fmt.Sprintf("File size %s exceeds default limit %s",
 				FormatFileSize(fileSize), FormatFileSize(result.MaxSize))
 			return result
@@ -188,6 +216,8 @@ func (e *ValidationEngine) applyDefaultAction(ext, filename, mimeType string, fi
 
 		// Check against default size limit
 		if fileSize > result.MaxSize {
+			result.IsAllowed = false
+			result.Code = "FILE_TOO_LARGE"
 			result.Reason = fmt.Sprintf("File size %s exceeds default limit %s",
 				FormatFileSize(fileSize), FormatFileSize(result.MaxSize))
 		}
@@ -234,6 +264,49 @@ func (e *ValidationEngine) GetBlockedExtensions() []string {
 	return blocked
 }
 
+// GetExtensionDetails returns the effective validation of every extension named by a rule, either
+// listed directly or as a simple "*.ext" pattern, in alphabetical order. Each extension is evaluated
+// through the engine, so overlapping rules resolve the same way they do for uploads.
+func (e *ValidationEngine) GetExtensionDetails() []ExtensionDetail {
+	seen := make(map[string]bool)
+	var extensions []string
+	addExtension := func(ext string) {
+		ext = utils.NormalizeExtension(ext)
+		if ext != "" && !seen[ext] {
+			seen[ext] = true
+			extensions = append(extensions, ext)
+		}
+	}
+
+	for _, rule := range e.config.Rules {
+		for _, ext := range rule.Extensions {
+			addExtension(ext)
+		}
+		for _, pattern := range rule.Patterns {
+			if ext, ok := strings.CutPrefix(pattern, "*."); ok && !strings.ContainsAny(ext, "*?") {
+				addExtension(ext)
+			}
+		}
+	}
+	sort.Strings(extensions)
+
+	details := make([]ExtensionDetail, 0, len(extensions))
+	for _, ext := range extensions {
+		result := e.ValidateFile("file."+ext, "", 0)
+		detail := ExtensionDetail{
+			Extension: ext,
+			Rule:      result.RuleName,
+			Allowed:   result.IsAllowed,
+			MaxSize:   result.MaxSize,
+		}
+		if result.MatchedRule != nil {
+			detail.MimeTypes = result.MatchedRule.MimeTypes
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
 // GetRuleByName finds a rule by its name
 func (e *ValidationEngine) GetRuleByName(name string) *config.ValidationRule {
 	for _, rule := range e.config.Rules {
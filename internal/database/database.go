@@ -32,11 +32,22 @@ func ConnectDB() error {
 	}
 
 	// Use go-pkg-database to open connection and auto-migrate
-	db, err := sql.OpenGorm(gormConfig, &models.File{})
+	db, err := sql.OpenGorm(gormConfig, &models.File{}, &models.MigrationCheckpoint{}, &models.Folder{}, &models.RejectedUpload{}, &models.PendingDeletion{}, &models.FileContent{}, &models.FilePathHistory{}, &models.FileTag{}, &models.FileDigest{}, &models.FileReference{})
 	if err != nil {
 		return err
 	}
 
 	DB = db.DB
+
+	// Index created_at for time-range queries and timeline aggregation
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_files_created_at ON files (created_at)").Error; err != nil {
+		return err
+	}
+
+	// Pattern-ops index so hash prefix (LIKE 'abc%') searches can use an index scan
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_files_hash_prefix ON files (hash text_pattern_ops)").Error; err != nil {
+		return err
+	}
+
 	return nil
 }
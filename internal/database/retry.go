@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts made for retryable database writes
+	DefaultRetryAttempts = 3
+	// DefaultRetryBaseDelay is the delay before the first retry; it doubles on each subsequent attempt
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+)
+
+// retryableSQLStates lists PostgreSQL error codes that indicate a transient failure
+var retryableSQLStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"08000": true, // connection_exception
+	"08001": true, // sqlclient_unable_to_establish_sqlconnection
+	"08003": true, // connection_does_not_exist
+	"08004": true, // sqlserver_rejected_establishment_of_sqlconnection
+	"08006": true, // connection_failure
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// IsRetryableError reports whether a database error is likely transient
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// pgconn.PgError exposes the SQLSTATE code through this method
+	var sqlStateErr interface{ SQLState() string }
+	if errors.As(err, &sqlStateErr) {
+		return retryableSQLStates[sqlStateErr.SQLState()]
+	}
+
+	return false
+}
+
+// WithRetry runs fn, retrying with exponential backoff while it fails with a retryable error
+func WithRetry(fn func() error) error {
+	return WithRetryAttempts(DefaultRetryAttempts, DefaultRetryBaseDelay, fn)
+}
+
+// WithRetryAttempts runs fn up to attempts times, doubling the delay after each retryable failure
+func WithRetryAttempts(attempts int, baseDelay time.Duration, fn func() error) error {
+	var err error
+	delay := baseDelay
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !IsRetryableError(err) {
+			return err
+		}
+
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// RetryPolicy bounds how long connecting to the database is retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxDuration time.Duration
+}
+
+var (
+	connectedMutex sync.Mutex
+	connected      bool
+	onConnected    []func()
+)
+
+// IsConnected reports whether the database connection has been established
+func IsConnected() bool {
+	connectedMutex.Lock()
+	defer connectedMutex.Unlock()
+	return connected
+}
+
+// WhenConnected runs fn once the database is connected; immediately if it already is
+func WhenConnected(fn func()) {
+	connectedMutex.Lock()
+	if !connected {
+		onConnected = append(onConnected, fn)
+		connectedMutex.Unlock()
+		return
+	}
+	connectedMutex.Unlock()
+	fn()
+}
+
+// markConnected records the established connection and runs the callbacks waiting for it
+func markConnected() {
+	connectedMutex.Lock()
+	connected = true
+	callbacks := onConnected
+	onConnected = nil
+	connectedMutex.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// ConnectWithRetry connects to the database, retrying with exponential backoff until it succeeds,
+// the attempts are used up or the maximum duration has elapsed
+func ConnectWithRetry(policy RetryPolicy) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	delay := policy.BaseDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+
+	start := time.Now()
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = ConnectDB(); err == nil {
+			markConnected()
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+		if policy.MaxDuration > 0 && time.Since(start)+delay > policy.MaxDuration {
+			break
+		}
+
+		log.Printf("Database connection attempt %d/%d failed, retrying in %s: %v", attempt, maxAttempts, delay, err)
+		time.Sleep(delay)
+
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+
+	return err
+}
+
+// ConnectInBackground keeps retrying the connection until it succeeds, for servers started in degraded mode
+func ConnectInBackground(policy RetryPolicy) {
+	interval := policy.MaxDelay
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			if err := ConnectDB(); err != nil {
+				log.Printf("Database still unavailable, retrying in %s: %v", interval, err)
+				continue
+			}
+
+			markConnected()
+			log.Println("Database connection established, leaving degraded mode")
+			return
+		}
+	}()
+}
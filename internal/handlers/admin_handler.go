@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"log"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+	"storage-api/internal/requests"
+	"storage-api/internal/services"
+	"storage-api/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kerimovok/go-pkg-utils/httpx"
+	"github.com/kerimovok/go-pkg-utils/validator"
+)
+
+// AdminHandler handles administrative maintenance requests
+type AdminHandler struct {
+	rehashService      *services.RehashService
+	maintenanceService *services.MaintenanceService
+	integrityService   *services.IntegrityService
+	reindexService     *services.ReindexService
+	thumbnailService   *services.ThumbnailService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		rehashService:      services.NewRehashService(),
+		maintenanceService: services.NewMaintenanceService(),
+		integrityService:   services.NewIntegrityService(),
+		reindexService:     services.NewReindexService(),
+		thumbnailService:   services.NewThumbnailService(),
+	}
+}
+
+// StartRehash starts the background re-hashing migration
+func (h *AdminHandler) StartRehash(c *fiber.Ctx) error {
+	if err := h.rehashService.Start(); err != nil {
+		response := httpx.Conflict("Failed to start rehash migration", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.Accepted("Rehash migration started", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// GetRehashProgress returns the progress of the re-hashing migration
+func (h *AdminHandler) GetRehashProgress(c *fiber.Ctx) error {
+	progress, err := h.rehashService.GetProgress()
+	if err != nil {
+		response := httpx.InternalServerError("Failed to fetch rehash progress", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Rehash progress retrieved successfully", progress)
+	return httpx.SendResponse(c, response)
+}
+
+// CheckStoredNames reports duplicate stored names and, with ?repair=true, renames duplicates
+// and rebuilds the unique index
+func (h *AdminHandler) CheckStoredNames(c *fiber.Ctx) error {
+	repair := c.QueryBool("repair", false)
+
+	report, err := h.maintenanceService.CheckStoredNames(repair)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to check stored names", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Stored name check completed", report)
+	return httpx.SendResponse(c, response)
+}
+
+// ListRejectedUploads lists recorded upload rejections, newest first
+func (h *AdminHandler) ListRejectedUploads(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := database.DB.Model(&models.RejectedUpload{})
+	if code := c.Query("reasonCode"); code != "" {
+		query = query.Where("reason_code = ?", code)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		response := httpx.InternalServerError("Failed to count rejected uploads", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var rejections []models.RejectedUpload
+	if err := query.Order("created_at DESC").Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&rejections).Error; err != nil {
+		response := httpx.InternalServerError("Failed to fetch rejected uploads", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	result := map[string]interface{}{
+		"rejections": rejections,
+		"pagination": map[string]interface{}{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	}
+
+	response := httpx.OK("Rejected uploads retrieved successfully", result)
+	return httpx.SendResponse(c, response)
+}
+
+// StartIntegrityCheck starts a background verification of stored content against recorded hashes
+func (h *AdminHandler) StartIntegrityCheck(c *fiber.Ctx) error {
+	if err := h.integrityService.Start(); err != nil {
+		response := httpx.Conflict("Failed to start integrity verification", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.Accepted("Integrity verification started", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// GetIntegrityStatus returns the state of the current or last integrity verification
+func (h *AdminHandler) GetIntegrityStatus(c *fiber.Ctx) error {
+	response := httpx.OK("Integrity status retrieved successfully", h.integrityService.GetStatus())
+	return httpx.SendResponse(c, response)
+}
+
+// StartReindex starts a background backfill of derived search fields for all files
+func (h *AdminHandler) StartReindex(c *fiber.Ctx) error {
+	if err := h.reindexService.Start(); err != nil {
+		response := httpx.Conflict("Failed to start search reindex", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.Accepted("Search reindex started", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// GetReindexStatus returns the progress of the current or last search reindex
+func (h *AdminHandler) GetReindexStatus(c *fiber.Ctx) error {
+	response := httpx.OK("Reindex status retrieved successfully", h.reindexService.GetStatus())
+	return httpx.SendResponse(c, response)
+}
+
+// RegenerateThumbnails starts a background run rendering the thumbnails of all or a filtered subset
+// of image files again with the current settings. With dryRun it only reports how many files would
+// be processed.
+func (h *AdminHandler) RegenerateThumbnails(c *fiber.Ctx) error {
+	var input requests.RegenerateThumbnailsRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			response := httpx.BadRequest("Invalid request body", err)
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	filter := services.ThumbnailFilter{Folder: input.Folder}
+	for _, ext := range input.Extensions {
+		filter.Extensions = append(filter.Extensions, utils.NormalizeExtension(ext))
+	}
+
+	if input.DryRun {
+		total, err := h.thumbnailService.CountRegeneration(filter)
+		if err != nil {
+			response := httpx.InternalServerError("Failed to count files", err)
+			return httpx.SendResponse(c, response)
+		}
+
+		response := httpx.OK("Thumbnail regeneration dry run completed", fiber.Map{
+			"dryRun": true,
+			"total":  total,
+		})
+		return httpx.SendResponse(c, response)
+	}
+
+	if err := h.thumbnailService.StartRegeneration(filter); err != nil {
+		response := httpx.Conflict("Failed to start thumbnail regeneration", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.Accepted("Thumbnail regeneration started", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// GetThumbnailRegenerationStatus returns the progress of the current or last thumbnail regeneration
+func (h *AdminHandler) GetThumbnailRegenerationStatus(c *fiber.Ctx) error {
+	response := httpx.OK("Thumbnail regeneration status retrieved successfully", h.thumbnailService.GetRegenerationStatus())
+	return httpx.SendResponse(c, response)
+}
+
+// GetEffectiveConfig returns the configuration in effect with secrets redacted
+func (h *AdminHandler) GetEffectiveConfig(c *fiber.Ctx) error {
+	effective, err := config.GetEffectiveConfig()
+	if err != nil {
+		response := httpx.InternalServerError("Failed to resolve configuration", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Configuration retrieved successfully", effective)
+	return httpx.SendResponse(c, response)
+}
+
+// GetMaintenance reports whether uploads are paused for maintenance
+func (h *AdminHandler) GetMaintenance(c *fiber.Ctx) error {
+	response := httpx.OK("Maintenance status retrieved successfully", services.GetUploadMaintenance())
+	return httpx.SendResponse(c, response)
+}
+
+// SetMaintenance pauses or resumes uploads; reads stay available either way
+func (h *AdminHandler) SetMaintenance(c *fiber.Ctx) error {
+	var input requests.SetMaintenanceRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	status := services.SetUploadMaintenance(*input.Enabled, input.Message, input.RetryAfterSeconds)
+	if status.Enabled {
+		log.Printf("Uploads paused for maintenance: %s", status.Message)
+	} else {
+		log.Println("Uploads resumed after maintenance")
+	}
+
+	response := httpx.OK("Maintenance status updated successfully", status)
+	return httpx.SendResponse(c, response)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kerimovok/go-pkg-utils/config"
+	"github.com/kerimovok/go-pkg-utils/httpx"
+)
+
+// AdminKeyHeader is the request header carrying the admin API key
+const AdminKeyHeader = "X-Admin-Key"
+
+// isAdminRequest reports whether the request carries the configured admin API key.
+// Without an ADMIN_API_KEY configured no request is treated as admin.
+func isAdminRequest(c *fiber.Ctx) bool {
+	adminKey := config.GetEnv("ADMIN_API_KEY")
+	if adminKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.Get(AdminKeyHeader)), []byte(adminKey)) == 1
+}
+
+// RequireAdmin is middleware that rejects requests without the admin API key with 403. It guards
+// the admin routes, whose operations affect every file rather than one.
+func RequireAdmin(c *fiber.Ctx) error {
+	if !isAdminRequest(c) {
+		response := httpx.Forbidden("Admin access required")
+		return httpx.SendResponse(c, response)
+	}
+	return c.Next()
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newAdminApp serves an admin route and a file route, with only the admin group guarded by
+// RequireAdmin as in the route setup
+func newAdminApp() *fiber.App {
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+
+	v1 := app.Group("/api/v1")
+	v1.Get("/files/:id", ok)
+	admin := v1.Group("/admin", RequireAdmin)
+	admin.Get("/config", ok)
+	admin.Post("/gc", ok)
+	return app
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminKey   string
+		method     string
+		path       string
+		header     string
+		wantStatus int
+	}{
+		{"admin key", "test-admin-key", http.MethodGet, "/api/v1/admin/config", "test-admin-key", http.StatusOK},
+		{"admin key on write", "test-admin-key", http.MethodPost, "/api/v1/admin/gc", "test-admin-key", http.StatusOK},
+		{"regular key", "test-admin-key", http.MethodGet, "/api/v1/admin/config", "some-api-key", http.StatusForbidden},
+		{"key prefix", "test-admin-key", http.MethodGet, "/api/v1/admin/config", "test-admin", http.StatusForbidden},
+		{"no key", "test-admin-key", http.MethodGet, "/api/v1/admin/config", "", http.StatusForbidden},
+		{"no admin key configured", "", http.MethodGet, "/api/v1/admin/config", "", http.StatusForbidden},
+		{"file routes need no key", "test-admin-key", http.MethodGet, "/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_API_KEY", tt.adminKey)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(AdminKeyHeader, tt.header)
+			}
+			resp, err := newAdminApp().Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -1,16 +1,37 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"storage-api/internal/config"
+	"storage-api/internal/constants"
 	"storage-api/internal/database"
 	"storage-api/internal/models"
 	"storage-api/internal/requests"
 	"storage-api/internal/services"
+	"storage-api/internal/utils"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	apperrors "github.com/kerimovok/go-pkg-utils/errors"
 	"github.com/kerimovok/go-pkg-utils/httpx"
 	"github.com/kerimovok/go-pkg-utils/validator"
 	"gorm.io/gorm"
@@ -18,24 +39,188 @@ import (
 
 // FileHandler handles file-related HTTP requests
 type FileHandler struct {
-	fileService *services.FileService
+	fileService      *services.FileService
+	previewService   *services.PreviewService
+	thumbnailService *services.ThumbnailService
+	downloadLimiter  *services.DownloadLimiter
+	scanService      *services.ScanService
+	folderService    *services.FolderService
+	urlFetcher       *services.URLFetcher
+	recordWriter     *services.FileRecordWriter
+	uploadBudget     *services.UploadBudget
+	diskSpaceGuard   *services.DiskSpaceGuard
+	storageQuota     *services.StorageQuota
+	uploadIPLimiter  *services.UploadIPLimiter
+	linkService      *services.LinkService
+	contentIndex     *services.ContentIndexService
+	tagService       *services.TagService
+	fileReferences   *services.FileReferenceService
 }
 
 // NewFileHandler creates a new file handler
 func NewFileHandler() *FileHandler {
 	return &FileHandler{
-		fileService: services.NewFileService(),
+		fileService:      services.NewFileService(),
+		previewService:   services.NewPreviewService(),
+		thumbnailService: services.NewThumbnailService(),
+		downloadLimiter:  services.NewDownloadLimiter(),
+		scanService:      services.NewScanService(),
+		folderService:    services.NewFolderService(),
+		urlFetcher:       services.NewURLFetcher(),
+		recordWriter:     services.NewFileRecordWriter(),
+		uploadBudget:     services.NewUploadBudget(),
+		diskSpaceGuard:   services.NewDiskSpaceGuard(),
+		storageQuota:     services.NewStorageQuota(),
+		uploadIPLimiter:  services.NewUploadIPLimiter(),
+		linkService:      services.NewLinkService(),
+		contentIndex:     services.NewContentIndexService(),
+		tagService:       services.NewTagService(),
+		fileReferences:   services.NewFileReferenceService(),
 	}
 }
 
+// reserveUploadBytes claims part of the concurrent upload budget, returning the release function
+// or the error response to send when the upload doesn't fit
+func (h *FileHandler) reserveUploadBytes(c *fiber.Ctx, size int64) (func(), *httpx.Response) {
+	if h.uploadBudget.Exceeds(size) {
+		response := httpx.PayloadTooLarge("Upload exceeds the concurrent upload capacity")
+		return nil, &response
+	}
+
+	release, ok := h.uploadBudget.Reserve(size)
+	if !ok {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(h.uploadBudget.GetRetryAfter()))
+		response := httpx.ServiceUnavailable("Upload capacity exhausted, please retry later")
+		return nil, &response
+	}
+	return release, nil
+}
+
+// lowDiskResponse returns the 507 response to send when free disk space is below a threshold that
+// applies to an upload of the given size
+func (h *FileHandler) lowDiskResponse(size int64) *httpx.Response {
+	var message string
+	switch h.diskSpaceGuard.Check(size) {
+	case services.LowDiskHard:
+		message = "Insufficient storage: free disk space is below the hard threshold, uploads are rejected"
+	case services.LowDiskSoft:
+		message = fmt.Sprintf("Insufficient storage: free disk space is below the soft threshold, only uploads up to %s are accepted",
+			constants.FormatFileSize(h.diskSpaceGuard.GetSoftMaxUploadSize()))
+	default:
+		return nil
+	}
+
+	response := httpx.CustomStatus(message, nil, fiber.StatusInsufficientStorage)
+	return &response
+}
+
+// setQuotaHeaders reports storage usage against the soft quota on a successful upload, with a
+// warning once usage passes the configured percentage. Failing to read the usage omits the headers.
+func (h *FileHandler) setQuotaHeaders(c *fiber.Ctx) {
+	if !h.storageQuota.IsEnabled() {
+		return
+	}
+
+	usage, err := h.storageQuota.GetUsage()
+	if err != nil {
+		log.Printf("Warning: Failed to read storage quota usage: %v", err)
+		return
+	}
+
+	c.Set("X-Storage-Quota-Used", strconv.FormatInt(usage.Used, 10))
+	c.Set("X-Storage-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+	if usage.Warning {
+		c.Set("X-Storage-Quota-Warning", fmt.Sprintf("Storage usage has reached %d%% of the quota (%s of %s)",
+			h.storageQuota.GetWarnPercent(), constants.FormatFileSize(usage.Used), constants.FormatFileSize(usage.Limit)))
+	}
+}
+
+// parseMultipartForm reads the request body as a multipart form, buffering file parts in memory up
+// to the configured limit and spilling the rest to temporary files. The body is read from the
+// request stream, so only the form's in-memory parts are held at once. The caller must remove the
+// form's temporary files with RemoveAll.
+func (h *FileHandler) parseMultipartForm(c *fiber.Ctx) (*multipart.Form, error) {
+	mediaType, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm || params["boundary"] == "" {
+		return nil, errors.New("request content type is not multipart/form-data with a boundary")
+	}
+
+	return multipart.NewReader(c.Context().RequestBodyStream(), params["boundary"]).ReadForm(h.fileService.GetMultipartMemory())
+}
+
+// formValue returns the first value of a multipart form field, or "" if it is absent. Fields are read
+// from the parsed form since Fiber's FormValue would parse the body a second time.
+func formValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// uploadMaintenanceResponse returns the 503 response to send while uploads are paused for maintenance
+func uploadMaintenanceResponse(c *fiber.Ctx) *httpx.Response {
+	status := services.GetUploadMaintenance()
+	if !status.Enabled {
+		return nil
+	}
+
+	message := status.Message
+	if message == "" {
+		message = "Uploads are paused for maintenance, please retry later"
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(status.RetryAfterSeconds))
+	response := httpx.ServiceUnavailable(message)
+	return &response
+}
+
+// acquireUploadSlot claims an upload slot for the client's address, returning the release function
+// or the error response to send when the per-IP concurrency or rate limit is reached
+func (h *FileHandler) acquireUploadSlot(c *fiber.Ctx) (func(), *httpx.Response) {
+	release, retryAfter, ok := h.uploadIPLimiter.Acquire(c.IP())
+	if !ok {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		response := httpx.TooManyRequests("Too many uploads from this address, please retry later")
+		return nil, &response
+	}
+	return release, nil
+}
+
 // UploadFile handles file upload requests
 func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
-	// Parse multipart form
-	form, err := c.MultipartForm()
+	if response := uploadMaintenanceResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Limit concurrent and repeated uploads from a single address
+	releaseSlot, errResponse := h.acquireUploadSlot(c)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer releaseSlot()
+
+	// Refuse uploads that would fill the disk before reading them
+	if response := h.lowDiskResponse(int64(max(c.Request().Header.ContentLength(), 0))); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Reserve the declared request size for the duration of the upload
+	release, errResponse := h.reserveUploadBytes(c, int64(max(c.Request().Header.ContentLength(), 0)))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer release()
+
+	// Parse multipart form; parts that spilled to disk are removed however the request ends
+	form, err := h.parseMultipartForm(c)
 	if err != nil {
 		response := httpx.BadRequest("Failed to parse multipart form", err)
 		return httpx.SendResponse(c, response)
 	}
+	defer func() {
+		if err := form.RemoveAll(); err != nil {
+			log.Printf("Warning: Failed to remove multipart temporary files: %v", err)
+		}
+	}()
 
 	// Get files from form
 	files := form.File["files"]
@@ -44,55 +229,85 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 		return httpx.SendResponse(c, response)
 	}
 
-	// Validate multiple files
-	if err := h.fileService.ValidateMultipleFiles(files); err != nil {
+	// Resolve the target folder before storing anything
+	folder, errResponse := h.resolveUploadFolder(formValue(form, "folder"))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	tags, err := h.tagService.ParseTags(form.Value["tags"]...)
+	if err != nil {
+		response := httpx.BadRequest("Invalid tags", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	initialStatus, errResponse := h.resolveUploadStatus(formValue(form, "status"))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	sources := services.NewUploadSourcesFromHeaders(files)
+
+	// Refuse names already used in the folder before anything is stored
+	if response := h.checkUniqueNames(folder, sources); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// In atomic mode the batch is stored and recorded completely or not at all
+	atomic := formValue(form, "atomic") == "true" || formValue(form, "atomic") == "1"
+
+	// Validate multiple files. In lenient mode invalid files are rejected individually, like files
+	// that fail to save, and the valid ones proceed.
+	var rejections []services.FileRejection
+	if h.fileService.GetUploadConfig().LenientValidation && !atomic {
+		sources, rejections, err = h.fileService.FilterValidFiles(sources)
+	} else {
+		err = h.fileService.ValidateMultipleFiles(sources)
+	}
+	if err != nil {
 		response := httpx.BadRequest("File validation failed", err)
+		var batchErr *services.BatchValidationError
+		if errors.As(err, &batchErr) {
+			response.Data = batchErr
+		}
 		return httpx.SendResponse(c, response)
 	}
 
 	// Process all files
-	uploadResults, err := h.fileService.ProcessMultipleFiles(files)
+	uploadResults, err := h.fileService.ProcessMultipleFiles(sources)
 	if err != nil {
 		response := httpx.InternalServerError("Failed to process files", err)
 		return httpx.SendResponse(c, response)
 	}
 
+	origin := newUploadOrigin(c, initialStatus)
+
+	if atomic {
+		return h.completeAtomicUpload(c, uploadResults, folder, tags, origin)
+	}
+
 	// Create file records for successful uploads
 	var fileRecords []models.File
 	var failedUploads []map[string]interface{}
 
+	for _, rejection := range rejections {
+		failedUploads = append(failedUploads, map[string]interface{}{
+			"original_name": rejection.FileName,
+			"error":         rejection.Reason,
+			"code":          rejection.Code,
+		})
+	}
+
 	for _, result := range uploadResults {
 		if result.Success {
-			// Create file record
-			fileRecord := models.File{
-				OriginalName: result.OriginalName,
-				StoredName:   result.StoredName,
-				FilePath:     result.FilePath,
-				FileSize:     result.FileSize,
-				MimeType:     result.MimeType,
-				Extension:    result.Extension,
-				FileType:     result.FileType,
-				Hash:         result.Hash,
-				Status:       "active",
-			}
-
-			// Save file record
-			if err := database.DB.Create(&fileRecord).Error; err != nil {
-				log.Printf("Failed to save file record for %s: %v", result.OriginalName, err)
-				// Mark as failed
-				result.Success = false
-				result.Error = "Failed to save file record"
-			} else {
-				fileRecords = append(fileRecords, fileRecord)
+			if fileRecord, err := h.createFileRecord(result, folder, tags, origin); err == nil {
+				fileRecords = append(fileRecords, *fileRecord)
 			}
 		}
 
 		// Add failed uploads to separate list
 		if !result.Success {
-			failedUploads = append(failedUploads, map[string]interface{}{
-				"original_name": result.OriginalName,
-				"error":         result.Error,
-			})
+			failedUploads = append(failedUploads, failedUpload(result))
 		}
 	}
 
@@ -122,12 +337,18 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 	var status int
 	if len(failedUploads) == 0 {
 		status = fiber.StatusCreated
+	} else if len(fileRecords) == 0 && len(rejections) == 0 && allDuplicates(uploadResults) {
+		status = fiber.StatusConflict
 	} else if len(fileRecords) == 0 {
 		status = fiber.StatusBadRequest
 	} else {
 		status = fiber.StatusPartialContent
 	}
 
+	if len(fileRecords) > 0 {
+		h.setQuotaHeaders(c)
+	}
+
 	response := httpx.Response{
 		Success: true,
 		Message: message,
@@ -138,53 +359,143 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 	return c.Status(status).JSON(response)
 }
 
-// GetFile retrieves file information or downloads the file based on query parameter
-func (h *FileHandler) GetFile(c *fiber.Ctx) error {
-	id := c.Params("id")
-	fileID, err := uuid.Parse(id)
+// UploadRawFile handles single-file uploads sent as a raw request body
+func (h *FileHandler) UploadRawFile(c *fiber.Ctx) error {
+	if response := uploadMaintenanceResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Limit concurrent and repeated uploads from a single address
+	releaseSlot, errResponse := h.acquireUploadSlot(c)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer releaseSlot()
+
+	// Get file name from header
+	fileName, err := url.PathUnescape(c.Get("X-File-Name"))
 	if err != nil {
-		response := httpx.BadRequest("Invalid file ID", err)
+		response := httpx.BadRequest("Invalid X-File-Name header", err)
+		return httpx.SendResponse(c, response)
+	}
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+		response := httpx.BadRequest("X-File-Name header is required", nil)
 		return httpx.SendResponse(c, response)
 	}
 
-	var file models.File
-	if err := database.DB.First(&file, fileID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response := httpx.NotFound("File not found")
-			return httpx.SendResponse(c, response)
-		}
-		response := httpx.InternalServerError("Failed to fetch file", err)
+	// Require an explicit Content-Length so size limits can be enforced up front
+	contentLength := c.Request().Header.ContentLength()
+	if contentLength < 0 {
+		response := httpx.LengthRequired("Content-Length header is required")
 		return httpx.SendResponse(c, response)
 	}
 
-	// Check if download is requested via query parameter
-	download := c.Query("download")
-	if download == "true" || download == "1" {
-		// Check if file exists on disk
-		if _, err := os.Stat(file.FilePath); os.IsNotExist(err) {
-			response := httpx.NotFound("File not found on disk")
-			return httpx.SendResponse(c, response)
-		}
+	body := c.Body()
+	if len(body) != contentLength {
+		response := httpx.BadRequest("Request body does not match Content-Length", nil)
+		return httpx.SendResponse(c, response)
+	}
+	if contentLength == 0 {
+		response := httpx.BadRequest("No file content provided", nil)
+		return httpx.SendResponse(c, response)
+	}
 
-		// Send file for download
-		return c.Download(file.FilePath, file.OriginalName)
+	if response := h.lowDiskResponse(int64(contentLength)); response != nil {
+		return httpx.SendResponse(c, *response)
 	}
 
-	// Return file metadata by default
-	response := httpx.OK("File retrieved successfully", file)
+	// Reserve the declared size for the duration of the upload
+	release, errResponse := h.reserveUploadBytes(c, int64(contentLength))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer release()
+
+	contentType := c.Get(fiber.HeaderContentType)
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	// Resolve the target folder before storing anything
+	folder, errResponse := h.resolveUploadFolder(c.Get("X-Folder", c.Query("folder")))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	tags, err := h.tagService.ParseTags(c.Get("X-Tags", c.Query("tags")))
+	if err != nil {
+		response := httpx.BadRequest("Invalid tags", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	initialStatus, errResponse := h.resolveUploadStatus(c.Get("X-Status", c.Query("status")))
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	sources := []*services.UploadSource{services.NewUploadSourceFromBytes(fileName, contentType, body)}
+
+	// Refuse names already used in the folder before anything is stored
+	if response := h.checkUniqueNames(folder, sources); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Validate file
+	if err := h.fileService.ValidateMultipleFiles(sources); err != nil {
+		response := httpx.BadRequest("File validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Process file
+	uploadResults, err := h.fileService.ProcessMultipleFiles(sources)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to process file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	result := uploadResults[0]
+	if result.Duplicate {
+		return httpx.SendResponse(c, h.duplicateFileResponse(result))
+	}
+	if !result.Success {
+		response := httpx.InternalServerError("Failed to store file", errors.New(result.Error))
+		return httpx.SendResponse(c, response)
+	}
+
+	fileRecord, err := h.createFileRecord(result, folder, tags, newUploadOrigin(c, initialStatus))
+	if err != nil {
+		if result.Duplicate {
+			return httpx.SendResponse(c, h.duplicateFileResponse(result))
+		}
+		response := httpx.InternalServerError("Failed to save file record", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	h.setQuotaHeaders(c)
+	response := httpx.Created("File uploaded successfully", fileRecord)
 	return httpx.SendResponse(c, response)
 }
 
-// UpdateFile updates file information
-func (h *FileHandler) UpdateFile(c *fiber.Ctx) error {
-	id := c.Params("id")
-	fileID, err := uuid.Parse(id)
-	if err != nil {
-		response := httpx.BadRequest("Invalid file ID", err)
+// UploadFromURL fetches a remote file and stores it like a regular upload
+func (h *FileHandler) UploadFromURL(c *fiber.Ctx) error {
+	if !h.urlFetcher.IsEnabled() {
+		response := httpx.NotFound("Upload from URL is not enabled")
 		return httpx.SendResponse(c, response)
 	}
 
-	var input requests.UpdateFileRequest
+	if response := uploadMaintenanceResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Limit concurrent and repeated uploads from a single address
+	releaseSlot, errResponse := h.acquireUploadSlot(c)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer releaseSlot()
+
+	var input requests.UploadFromURLRequest
 	if err := c.BodyParser(&input); err != nil {
 		response := httpx.BadRequest("Invalid request body", err)
 		return httpx.SendResponse(c, response)
@@ -196,167 +507,2613 @@ func (h *FileHandler) UpdateFile(c *fiber.Ctx) error {
 		return httpx.SendResponse(c, response)
 	}
 
-	var file models.File
-	if err := database.DB.First(&file, fileID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response := httpx.NotFound("File not found")
-			return httpx.SendResponse(c, response)
-		}
-		response := httpx.InternalServerError("Failed to fetch file", err)
+	// Resolve the target folder before fetching anything
+	folder, errResponse := h.resolveUploadFolder(input.Folder)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	tags, err := h.tagService.NormalizeTags(input.Tags)
+	if err != nil {
+		response := httpx.BadRequest("Invalid tags", err)
 		return httpx.SendResponse(c, response)
 	}
 
-	// Update fields
-	updates := make(map[string]interface{})
-	if input.FileName != nil {
-		updates["original_name"] = *input.FileName
+	initialStatus, errResponse := h.resolveUploadStatus(input.Status)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
 	}
-	if input.Status != nil {
-		updates["status"] = input.Status
+
+	fileName := ""
+	if input.FileName != "" {
+		fileName = filepath.Base(strings.TrimSpace(input.FileName))
 	}
 
-	if len(updates) > 0 {
-		if err := database.DB.Model(&file).Updates(updates).Error; err != nil {
-			response := httpx.InternalServerError("Failed to update file", err)
+	fetched, err := h.urlFetcher.Fetch(input.URL, fileName)
+	if err != nil {
+		var appErr *apperrors.Error
+		switch {
+		case errors.As(err, &appErr) && appErr.Type == apperrors.ErrorTypeTimeout:
+			response := httpx.GatewayTimeout(appErr.Message)
+			return httpx.SendResponse(c, response)
+		case errors.As(err, &appErr) && appErr.Code == "FILE_TOO_LARGE":
+			response := httpx.PayloadTooLarge(appErr.Message)
+			return httpx.SendResponse(c, response)
+		default:
+			response := httpx.BadRequest("Failed to fetch remote file", err)
 			return httpx.SendResponse(c, response)
 		}
 	}
+	defer fetched.Cleanup()
 
-	response := httpx.OK("File updated successfully", file)
-	return httpx.SendResponse(c, response)
-}
+	if response := h.lowDiskResponse(fetched.Source.Size); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
 
-// DeleteFile deletes a file
-func (h *FileHandler) DeleteFile(c *fiber.Ctx) error {
-	id := c.Params("id")
-	fileID, err := uuid.Parse(id)
-	if err != nil {
-		response := httpx.BadRequest("Invalid file ID", err)
+	// The size of a remote file is only known once fetched, so reserve it before processing
+	release, errResponse := h.reserveUploadBytes(c, fetched.Source.Size)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	defer release()
+
+	sources := []*services.UploadSource{fetched.Source}
+
+	// Refuse names already used in the folder before anything is stored
+	if response := h.checkUniqueNames(folder, sources); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Validate file
+	if err := h.fileService.ValidateMultipleFiles(sources); err != nil {
+		response := httpx.BadRequest("File validation failed", err)
 		return httpx.SendResponse(c, response)
 	}
 
-	var file models.File
-	if err := database.DB.First(&file, fileID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response := httpx.NotFound("File not found")
-			return httpx.SendResponse(c, response)
-		}
-		response := httpx.InternalServerError("Failed to fetch file", err)
+	// Process file
+	uploadResults, err := h.fileService.ProcessMultipleFiles(sources)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to process file", err)
 		return httpx.SendResponse(c, response)
 	}
 
-	// Delete file record
-	if err := database.DB.Delete(&file).Error; err != nil {
-		response := httpx.InternalServerError("Failed to delete file", err)
+	result := uploadResults[0]
+	if result.Duplicate {
+		return httpx.SendResponse(c, h.duplicateFileResponse(result))
+	}
+	if !result.Success {
+		response := httpx.InternalServerError("Failed to store file", errors.New(result.Error))
 		return httpx.SendResponse(c, response)
 	}
 
-	// Delete file from disk
-	if err := os.Remove(file.FilePath); err != nil {
-		log.Printf("Warning: Failed to delete file from disk: %v", err)
+	fileRecord, err := h.createFileRecord(result, folder, tags, newUploadOrigin(c, initialStatus))
+	if err != nil {
+		if result.Duplicate {
+			return httpx.SendResponse(c, h.duplicateFileResponse(result))
+		}
+		response := httpx.InternalServerError("Failed to save file record", err)
+		return httpx.SendResponse(c, response)
 	}
 
-	response := httpx.OK("File deleted successfully", nil)
+	h.setQuotaHeaders(c)
+	response := httpx.Created("File uploaded successfully", fileRecord)
 	return httpx.SendResponse(c, response)
 }
 
-// SearchFiles searches for files based on criteria
-func (h *FileHandler) SearchFiles(c *fiber.Ctx) error {
-	var input requests.FileSearchRequest
-	if err := c.QueryParser(&input); err != nil {
-		response := httpx.BadRequest("Invalid query parameters", err)
-		return httpx.SendResponse(c, response)
+// statusController names what holds a file in a blocked status, or returns "" when clients may
+// change the status
+func statusController(status string) string {
+	switch status {
+	case "quarantined", "infected":
+		return "virus scanning"
+	case "corrupted":
+		return "integrity verification"
 	}
+	return ""
+}
 
-	// Validate request
-	if err := validator.ValidateStruct(&input); err != nil {
-		response := httpx.BadRequest("Validation failed", err)
-		return httpx.SendResponse(c, response)
+// blockedStatusResponse returns the error response for statuses whose content must not be served
+func blockedStatusResponse(status string) *httpx.Response {
+	var response httpx.Response
+	switch status {
+	case "quarantined":
+		response = httpx.Locked("File is quarantined pending virus scan")
+	case "infected":
+		response = httpx.Forbidden("File failed virus scan")
+	case "corrupted":
+		response = httpx.Conflict("File failed integrity verification", nil)
+	default:
+		return nil
 	}
+	return &response
+}
 
-	// Set defaults
-	if input.Page <= 0 {
-		input.Page = 1
+// hotlinkResponse returns the error response for content requests referred by a site outside the
+// hotlink protection allowlist; admin requests are exempt
+func (h *FileHandler) hotlinkResponse(c *fiber.Ctx) *httpx.Response {
+	if !h.fileService.IsHotlinkProtectionEnabled() || isAdminRequest(c) {
+		return nil
 	}
-	if input.Limit <= 0 {
-		input.Limit = 20
+	if !h.fileService.IsRefererAllowed(c.Get(fiber.HeaderOrigin), c.Get(fiber.HeaderReferer)) {
+		response := httpx.Forbidden("Downloads are not allowed from this site")
+		return &response
 	}
-	if input.SortBy == "" {
-		input.SortBy = "created_at"
+	return nil
+}
+
+// signedLinkResponse returns the error response for content requests lacking a valid link
+// signature when signing is enabled; admin requests don't need one
+func (h *FileHandler) signedLinkResponse(c *fiber.Ctx) *httpx.Response {
+	if !h.linkService.IsSigningEnabled() || isAdminRequest(c) {
+		return nil
 	}
-	if input.SortOrder == "" {
-		input.SortOrder = "desc"
+	if err := h.linkService.VerifySignature(c.Path(), c.Query("expires"), c.Query("signature")); err != nil {
+		response := httpx.Forbidden("Invalid or expired link")
+		return &response
 	}
+	return nil
+}
 
-	// Build query
-	query := database.DB.Model(&models.File{})
+// includes reports whether the client asked for an optional response field via ?include=
+// (a comma-separated list such as ?include=urls,content)
+func includes(c *fiber.Ctx, field string) bool {
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == field {
+			return true
+		}
+	}
+	return false
+}
 
-	// Apply filters
-	if input.FileType != "" {
-		query = query.Where("file_type = ?", input.FileType)
+// embedContent sets a file's base64-encoded content, returning the error response when the file
+// can't be embedded
+func (h *FileHandler) embedContent(file *models.File) *httpx.Response {
+	maxSize := h.fileService.GetInlineContentMaxSize()
+	if maxSize == 0 {
+		response := httpx.BadRequest("Content embedding is not enabled", nil)
+		return &response
 	}
-	if input.Status != "" {
-		query = query.Where("status = ?", input.Status)
+	if file.FileSize > maxSize {
+		response := httpx.BadRequest(fmt.Sprintf("File is too large to embed; the limit is %s", constants.FormatFileSize(maxSize)), nil)
+		return &response
 	}
-	if input.UploadedAfter != nil {
-		query = query.Where("created_at >= ?", input.UploadedAfter)
+
+	// Embedded content is subject to the same checks as downloads
+	if response := blockedStatusResponse(file.Status); response != nil {
+		return response
 	}
-	if input.UploadedBefore != nil {
-		query = query.Where("created_at <= ?", input.UploadedBefore)
+	if response := accessWindowResponse(file); response != nil {
+		return response
 	}
 
-	// Get total count
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		response := httpx.InternalServerError("Failed to count files", err)
-		return httpx.SendResponse(c, response)
+	content, err := h.fileService.ReadInlineContent(file)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to read file content", err)
+		return &response
 	}
+	file.ContentBase64 = content
+	return nil
+}
 
-	// Apply sorting and pagination
-	offset := (input.Page - 1) * input.Limit
-	query = query.Order(input.SortBy + " " + input.SortOrder).
-		Offset(offset).
-		Limit(input.Limit)
+// attachLinks sets the computed download, thumbnail and preview URLs on a file
+func (h *FileHandler) attachLinks(file *models.File) {
+	file.Links = h.linkService.BuildLinks(file, h.thumbnailService, h.previewService)
+}
 
-	var files []models.File
-	if err := query.Find(&files).Error; err != nil {
-		response := httpx.InternalServerError("Failed to fetch files", err)
-		return httpx.SendResponse(c, response)
+// checkUniqueNames returns a conflict response when names must be unique within a folder and an
+// upload reuses a name already taken there or repeats a name within the same request
+func (h *FileHandler) checkUniqueNames(folder string, sources []*services.UploadSource) *httpx.Response {
+	if !h.folderService.IsUniqueNamesEnabled() {
+		return nil
 	}
 
-	// Build response
-	result := map[string]interface{}{
-		"files": files,
-		"pagination": map[string]interface{}{
-			"page":       input.Page,
-			"limit":      input.Limit,
-			"total":      total,
-			"totalPages": (total + int64(input.Limit) - 1) / int64(input.Limit),
-		},
+	names := make([]string, 0, len(sources))
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		name, _ := h.fileService.NormalizeOriginalName(source.Filename)
+		if seen[name] {
+			response := httpx.Conflict("Duplicate file name in upload", fmt.Errorf("%s is included more than once", name))
+			return &response
+		}
+		seen[name] = true
+		names = append(names, name)
 	}
 
-	response := httpx.OK("Files retrieved successfully", result)
-	return httpx.SendResponse(c, response)
+	taken, err := h.folderService.FindTakenNames(folder, names, uuid.Nil)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to check file names", err)
+		return &response
+	}
+	if len(taken) > 0 {
+		response := httpx.Conflict("A file with the same name already exists in the folder", fmt.Errorf("name already taken: %s", strings.Join(taken, ", ")))
+		return &response
+	}
+	return nil
 }
 
-// GetFileLimits returns file size limits for different extensions
-func (h *FileHandler) GetFileLimits(c *fiber.Ctx) error {
+// accessWindowResponse returns the error response when a file's content is requested outside
+// its access window; metadata stays available regardless
+func accessWindowResponse(file *models.File) *httpx.Response {
+	now := time.Now()
+	if file.AccessibleFrom != nil && now.Before(*file.AccessibleFrom) {
+		response := httpx.Forbidden("File is not accessible yet")
+		return &response
+	}
+	if file.AccessibleUntil != nil && !now.Before(*file.AccessibleUntil) {
+		response := httpx.Forbidden("File is no longer accessible")
+		return &response
+	}
+	return nil
+}
+
+// resolveUploadStatus validates the status an upload asked its files to start in, falling back to
+// the configured default when none was given
+func (h *FileHandler) resolveUploadStatus(status string) (string, *httpx.Response) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		return h.fileService.GetUploadConfig().GetDefaultStatus(), nil
+	}
+	if !config.IsSupportedUploadStatus(status) {
+		response := httpx.BadRequest("Invalid status", fmt.Errorf("uploads may start as %s", strings.Join(config.UploadStatuses, ", ")))
+		return "", &response
+	}
+	return status, nil
+}
+
+// resolveUploadFolder validates an upload's target folder, creating it when auto-creation is enabled.
+// It returns the normalized path, or the error response to send when the folder can't be used.
+func (h *FileHandler) resolveUploadFolder(rawFolder string) (string, *httpx.Response) {
+	folder, err := h.folderService.NormalizePath(rawFolder)
+	if err != nil {
+		response := httpx.BadRequest("Invalid folder", err)
+		return "", &response
+	}
+
+	exists, err := h.folderService.Exists(folder)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to look up folder", err)
+		return "", &response
+	}
+	if exists {
+		return folder, nil
+	}
+
+	if !h.folderService.AutoCreate() {
+		response := httpx.NotFound(fmt.Sprintf("Folder '%s' does not exist", folder))
+		return "", &response
+	}
+
+	if _, err := h.folderService.CreateFolder(folder); err != nil {
+		response := httpx.InternalServerError("Failed to create folder", err)
+		return "", &response
+	}
+
+	return folder, nil
+}
+
+// completeAtomicUpload records an atomic batch, discarding everything stored if any file failed
+func (h *FileHandler) completeAtomicUpload(c *fiber.Ctx, uploadResults []*services.FileUploadResult, folder string, tags []string, origin uploadOrigin) error {
+	var failedUploads []map[string]interface{}
+	for _, result := range uploadResults {
+		if !result.Success {
+			failedUploads = append(failedUploads, failedUpload(result))
+		}
+	}
+
+	if len(failedUploads) > 0 {
+		h.discardStoredUploads(uploadResults)
+		response := httpx.BadRequest("Atomic upload failed, no files were stored", nil)
+		if allDuplicates(uploadResults) {
+			response = httpx.Conflict("Atomic upload failed, no files were stored", nil)
+		}
+		response.Data = map[string]interface{}{
+			"total_files":    len(uploadResults),
+			"failed":         len(failedUploads),
+			"failed_uploads": failedUploads,
+		}
+		return httpx.SendResponse(c, response)
+	}
+
+	fileRecords, err := h.createFileRecordsAtomically(uploadResults, folder, tags, origin)
+	if err != nil {
+		log.Printf("Atomic upload of %d files rolled back: %v", len(uploadResults), err)
+		response := httpx.InternalServerError("Atomic upload failed, no files were stored", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	h.setQuotaHeaders(c)
+	response := httpx.Created("All files uploaded successfully", map[string]interface{}{
+		"uploaded_files": fileRecords,
+		"total_files":    len(uploadResults),
+		"successful":     len(fileRecords),
+		"failed":         0,
+	})
+	return httpx.SendResponse(c, response)
+}
+
+// uploadOrigin identifies the request and client a file was uploaded by, and the status the
+// request asked its files to have
+type uploadOrigin struct {
+	requestID string
+	ip        string
+	userAgent string
+	status    string
+}
+
+// newUploadOrigin captures the origin of an upload request. The client IP honors the proxy header
+// only for requests from trusted proxies.
+func newUploadOrigin(c *fiber.Ctx, status string) uploadOrigin {
+	return uploadOrigin{
+		status:    status,
+		requestID: c.GetRespHeader(fiber.HeaderXRequestID),
+		ip:        c.IP(),
+		userAgent: truncateUserAgent(c.Get(fiber.HeaderUserAgent)),
+	}
+}
+
+// truncateUserAgent bounds the stored User-Agent, which clients control
+func truncateUserAgent(userAgent string) string {
+	const maxLength = 512
+	if len(userAgent) <= maxLength {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:maxLength], "")
+}
+
+// createFileRecord persists a successfully stored file with its tags, marking the result as failed on error
+func (h *FileHandler) createFileRecord(result *services.FileUploadResult, folder string, tags []string, origin uploadOrigin) (*models.File, error) {
+	tags = h.tagService.WithCategoryTag(result.OriginalName, tags)
+
+	// A merged batch duplicate references the record created for the first copy
+	if err := resolveBatchDuplicate(result); err != nil {
+		return nil, err
+	}
+
+	// Deduplicated uploads reference the existing file; no new record or bytes are written
+	if result.Deduplicated {
+		var existing models.File
+		if err := database.DB.Where("id = ?", result.ExistingFileID).First(&existing).Error; err != nil {
+			result.Success = false
+			result.Error = "Failed to load deduplicated file"
+			return nil, err
+		}
+		h.tagDeduplicatedFile(&existing, result, tags)
+		return &existing, nil
+	}
+
+	fileRecord := h.newFileRecord(result, folder, origin)
+
+	// Save file record, retrying transient database failures
+	if err := h.recordWriter.Create(&fileRecord); err != nil {
+		log.Printf("Failed to save file record for %s: %v", result.OriginalName, err)
+
+		// Remove the already-saved files so they don't become orphans. Backends create stored files
+		// exclusively, so these paths belong to this upload and no other record's content is lost.
+		if err := h.fileService.DeleteStoredFile(result.Backend, result.FilePath, result.OriginalFilePath); err != nil {
+			log.Printf("Warning: Failed to remove orphaned file %s: %v", result.FilePath, err)
+		}
+
+		// Mark as failed, reporting a concurrent upload of the same content as a duplicate
+		result.Success = false
+		result.Error = "Failed to save file record"
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if existing, _ := h.fileService.FindFileByHash(result.Hash, result.HashAlgorithm); existing != nil {
+				*result = *services.NewDuplicateFileResult(result.OriginalName, existing)
+			}
+		}
+		return nil, err
+	}
+
+	// Start the file's path history with its initial location
+	if err := services.RecordFilePath(database.DB, &fileRecord, services.PathReasonUploaded); err != nil {
+		log.Printf("Warning: Failed to record path history for %s: %v", result.OriginalName, err)
+	}
+
+	result.RecordID = fileRecord.ID.String()
+	h.finishFileRecord(&fileRecord, result, tags, origin.status)
+	return &fileRecord, nil
+}
+
+// resolveBatchDuplicate points a merged batch duplicate at the record of the first copy, failing it
+// when that copy couldn't be recorded
+func resolveBatchDuplicate(result *services.FileUploadResult) error {
+	if result.BatchDuplicateOf == nil || !result.Deduplicated {
+		return nil
+	}
+	if result.BatchDuplicateOf.RecordID == "" {
+		result.Success = false
+		result.Error = "Failed to save file record"
+		return fmt.Errorf("first copy %s of %s was not recorded", result.BatchDuplicateOf.OriginalName, result.OriginalName)
+	}
+	result.ExistingFileID = result.BatchDuplicateOf.RecordID
+	return nil
+}
+
+// failedUpload describes a file of a batch that wasn't stored
+func failedUpload(result *services.FileUploadResult) map[string]interface{} {
+	entry := map[string]interface{}{
+		"original_name": result.OriginalName,
+		"error":         result.Error,
+	}
+	switch {
+	case result.Duplicate && result.BatchDuplicateOf != nil:
+		entry["code"] = services.BatchDuplicateErrorCode
+		entry["duplicate_of"] = result.BatchDuplicateOf.OriginalName
+	case result.Duplicate:
+		entry["code"] = services.DuplicateFileErrorCode
+		entry["existing_file_id"] = result.ExistingFileID
+	}
+	return entry
+}
+
+// allDuplicates reports whether every failed result of a batch failed because its content already exists
+func allDuplicates(results []*services.FileUploadResult) bool {
+	failed := false
+	for _, result := range results {
+		if result.Success {
+			continue
+		}
+		if !result.Duplicate {
+			return false
+		}
+		failed = true
+	}
+	return failed
+}
+
+// duplicateFileResponse returns the 409 response for an upload whose content is already stored,
+// carrying the existing file so the client can use it instead
+func (h *FileHandler) duplicateFileResponse(result *services.FileUploadResult) httpx.Response {
+	response := httpx.Conflict("A file with identical content already exists", nil)
+	response.Data = fiber.Map{"existingFileId": result.ExistingFileID}
+
+	var existing models.File
+	if err := database.DB.Where("id = ?", result.ExistingFileID).First(&existing).Error; err == nil {
+		if tags, err := h.tagService.GetTags(existing.ID); err == nil {
+			existing.Tags = tags
+		}
+		response.Data = fiber.Map{
+			"existingFileId": result.ExistingFileID,
+			"existingFile":   existing,
+		}
+	}
+	return response
+}
+
+// createFileRecordsAtomically persists every stored file of a batch in a single transaction.
+// If any record can't be saved none are kept, and the stored content of the whole batch is removed.
+func (h *FileHandler) createFileRecordsAtomically(results []*services.FileUploadResult, folder string, tags []string, origin uploadOrigin) ([]models.File, error) {
+	records := make([]models.File, len(results))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, result := range results {
+			if err := resolveBatchDuplicate(result); err != nil {
+				return err
+			}
+			if result.Deduplicated {
+				if err := tx.Where("id = ?", result.ExistingFileID).First(&records[i]).Error; err != nil {
+					return fmt.Errorf("failed to load deduplicated file for %s: %w", result.OriginalName, err)
+				}
+				continue
+			}
+
+			records[i] = h.newFileRecord(result, folder, origin)
+			if err := h.recordWriter.CreateInTransaction(tx, &records[i]); err != nil {
+				return fmt.Errorf("failed to save file record for %s: %w", result.OriginalName, err)
+			}
+			if err := services.RecordFilePath(tx, &records[i], services.PathReasonUploaded); err != nil {
+				return fmt.Errorf("failed to record path history for %s: %w", result.OriginalName, err)
+			}
+			result.RecordID = records[i].ID.String()
+		}
+		return nil
+	})
+	if err != nil {
+		h.discardStoredUploads(results)
+		return nil, err
+	}
+
+	// Tags and derived content are only added once the whole batch is committed
+	for i, result := range results {
+		fileTags := h.tagService.WithCategoryTag(result.OriginalName, tags)
+		if result.Deduplicated {
+			h.tagDeduplicatedFile(&records[i], result, fileTags)
+			continue
+		}
+		h.finishFileRecord(&records[i], result, fileTags, origin.status)
+	}
+	return records, nil
+}
+
+// discardStoredUploads removes the content written for the successful results of a batch
+func (h *FileHandler) discardStoredUploads(results []*services.FileUploadResult) {
+	for _, result := range results {
+		if !result.Success || result.Deduplicated {
+			continue
+		}
+		if err := h.fileService.DeleteStoredFile(result.Backend, result.FilePath, result.OriginalFilePath); err != nil {
+			log.Printf("Warning: Failed to remove stored file %s: %v", result.FilePath, err)
+		}
+	}
+}
+
+// newFileRecord builds the record for a file stored by an upload
+func (h *FileHandler) newFileRecord(result *services.FileUploadResult, folder string, origin uploadOrigin) models.File {
+	originalName, rawOriginalName := h.fileService.NormalizeOriginalName(result.OriginalName)
+
+	fileRecord := models.File{
+		OriginalName:     originalName,
+		RawOriginalName:  rawOriginalName,
+		Folder:           folder,
+		StoredName:       result.StoredName,
+		FilePath:         result.FilePath,
+		Backend:          result.Backend,
+		FileSize:         result.FileSize,
+		MimeType:         result.MimeType,
+		DeclaredMimeType: result.DeclaredMimeType,
+		Extension:        result.Extension,
+		FileType:         result.FileType,
+		Hash:             result.Hash,
+		HashAlgorithm:    result.HashAlgorithm,
+		Status:           h.scanService.InitialStatus(origin.status),
+		PerceptualHash:   result.PerceptualHash,
+		OriginalFilePath: result.OriginalFilePath,
+	}
+	if h.fileService.IsRequestIDRecorded() {
+		fileRecord.CreatedByRequestID = origin.requestID
+	}
+	if h.fileService.IsUploaderRecorded() {
+		fileRecord.UploaderIP = origin.ip
+		fileRecord.UploaderUserAgent = origin.userAgent
+	}
+	return fileRecord
+}
+
+// tagDeduplicatedFile adds an upload's tags to the existing file it was deduplicated against
+func (h *FileHandler) tagDeduplicatedFile(existing *models.File, result *services.FileUploadResult, tags []string) {
+	existing.Deduplicated = true
+
+	// The upload's tags are added to those the existing file already carries
+	if err := h.tagService.AddTags(existing.ID, tags); err != nil {
+		log.Printf("Warning: Failed to tag %s: %v", result.OriginalName, err)
+	}
+	if existingTags, err := h.tagService.GetTags(existing.ID); err == nil {
+		existing.Tags = existingTags
+	}
+}
+
+// finishFileRecord tags a newly saved file and starts its derived content and background processing.
+// Quarantined files move to status once they scan clean.
+func (h *FileHandler) finishFileRecord(fileRecord *models.File, result *services.FileUploadResult, tags []string, status string) {
+	if err := h.tagService.AddTags(fileRecord.ID, tags); err != nil {
+		log.Printf("Warning: Failed to tag %s: %v", result.OriginalName, err)
+	} else {
+		fileRecord.Tags = tags
+	}
+
+	// Precompress a gzip variant for compressible content
+	if h.fileService.ShouldPrecompress(fileRecord) {
+		if created, err := h.fileService.CreateGzipVariant(fileRecord); err != nil {
+			log.Printf("Warning: Failed to create gzip variant for %s: %v", result.OriginalName, err)
+		} else if created {
+			fileRecord.HasGzipVariant = true
+			if err := database.DB.Model(fileRecord).Update("has_gzip_variant", true).Error; err != nil {
+				log.Printf("Warning: Failed to record gzip variant for %s: %v", result.OriginalName, err)
+			}
+		}
+	}
+
+	// Quarantined files become available once the background scan comes back clean
+	if h.scanService.IsEnabled() {
+		h.scanService.ScanAsync(*fileRecord, status)
+	}
+
+	// Extract document text for content search
+	if h.contentIndex.IsEnabled() && h.contentIndex.SupportsFile(fileRecord) {
+		h.contentIndex.IndexAsync(*fileRecord)
+	}
+
+	// Render preview in the background if configured; quarantined files are rendered on first request once clean
+	if h.previewService.RenderOnUpload() && h.previewService.SupportsFile(fileRecord) && blockedStatusResponse(fileRecord.Status) == nil {
+		h.previewService.RenderAsync(*fileRecord)
+	}
+}
+
+// GetFile retrieves file information or downloads the file based on query parameter
+func (h *FileHandler) GetFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Check if download or inline serving is requested via query parameters
+	download := c.Query("download")
+	inline := c.Query("inline")
+	if download == "true" || download == "1" || inline == "true" || inline == "1" {
+		if response := h.signedLinkResponse(c); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		if response := h.hotlinkResponse(c); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+
+		// Files pending or failing a virus scan or integrity check are never served
+		if response := blockedStatusResponse(file.Status); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		if response := accessWindowResponse(&file); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+
+		// Check if the file exists in its backend
+		if _, err := h.fileService.GetBackend(file.Backend).Stat(file.FilePath); os.IsNotExist(err) {
+			response := httpx.NotFound("File not found on disk")
+			return httpx.SendResponse(c, response)
+		}
+
+		// Charset conversion changes the bytes, so it's handled before content ETags apply
+		if charset := c.Query("charset"); charset != "" {
+			return h.sendTranscodedDownload(c, &file, charset)
+		}
+
+		// Serve the precompressed variant to clients that accept gzip
+		useGzip := h.fileService.ShouldServeGzip(&file, c.Get(fiber.HeaderAcceptEncoding))
+		if file.HasGzipVariant {
+			c.Vary(fiber.HeaderAcceptEncoding)
+		}
+
+		// Emit a strong content ETag and honor conditional requests
+		if h.fileService.IsStrongETagEnabled() {
+			etag := services.ContentETag(&file)
+			if useGzip {
+				etag = services.GzipETag(etag)
+			}
+			c.Set(fiber.HeaderETag, etag)
+			if utils.ETagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+		}
+
+		// Advertise the stored digest, which only describes the unencoded content. Downloads skip the
+		// compress middleware, and no-transform asks proxies not to re-encode the body either.
+		if h.fileService.IsDigestHeaderEnabled() && !useGzip {
+			if digest, ok := services.ContentDigest(&file); ok {
+				c.Set("Digest", digest)
+				c.Set(fiber.HeaderCacheControl, "no-transform")
+			}
+			// Content-MD5 covers the message body, so it's left off partial responses
+			if contentMD5, ok := services.ContentMD5(&file); ok && c.Get(fiber.HeaderRange) == "" {
+				c.Set("Content-MD5", contentMD5)
+				c.Set(fiber.HeaderCacheControl, "no-transform")
+			}
+		}
+
+		// Never let browsers guess a more dangerous type than the one we send
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+		// Active content (SVG/HTML) is forced to attachment unless explicitly allowed
+		serveInline := (inline == "true" || inline == "1") && h.fileService.CanServeInline(&file)
+		if serveInline {
+			c.Set(fiber.HeaderContentSecurityPolicy, h.fileService.GetContentSecurityPolicy())
+		}
+
+		if useGzip {
+			c.Set(fiber.HeaderContentEncoding, "gzip")
+			return h.streamFile(c, &file, services.GzipVariantPath(&file), serveInline)
+		}
+
+		// Only complete bodies can be verified, so range requests keep the regular file sender
+		verify := h.fileService.IsDownloadVerificationEnabled() && c.Get(fiber.HeaderRange) == ""
+		if h.downloadLimiter.IsEnabled() || verify {
+			return h.streamFile(c, &file, file.FilePath, serveInline)
+		}
+
+		if serveInline {
+			c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("inline", &file))
+			err = h.sendStoredFile(c, &file, file.FilePath)
+		} else {
+			// Send file for download
+			c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("attachment", &file))
+			err = h.sendStoredFile(c, &file, file.FilePath)
+		}
+
+		// The file sender derives Content-Type from the extension, so apply any override afterwards
+		if contentType := services.ServedContentType(&file); err == nil && contentType != "" {
+			c.Set(fiber.HeaderContentType, contentType)
+		}
+		return err
+	}
+
+	return h.sendFileMetadata(c, &file)
+}
+
+// GetFileByStoredName returns the metadata of the file with the given stored name, for
+// integrations that reference files by stored name rather than ID
+func (h *FileHandler) GetFileByStoredName(c *fiber.Ctx) error {
+	storedName, err := url.PathUnescape(c.Params("storedName"))
+	if err != nil {
+		response := httpx.BadRequest("Invalid stored name", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Stored names are single path segments generated by the service
+	storedName = strings.TrimSpace(storedName)
+	if storedName == "" || len(storedName) > 255 || storedName == "." || storedName == ".." ||
+		strings.ContainsAny(storedName, "/\\\x00") {
+		response := httpx.BadRequest("Invalid stored name", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.Where("stored_name = ?", storedName).First(&file).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	return h.sendFileMetadata(c, &file)
+}
+
+// sendFileMetadata responds with a file's metadata, adding the admin-only details and optional
+// includes the request asks for
+func (h *FileHandler) sendFileMetadata(c *fiber.Ctx, file *models.File) error {
+	// Storage location is infrastructure detail, reported only to admins when enabled
+	if h.fileService.IsStorageLocationExposed() && isAdminRequest(c) {
+		file.StorageLocation = h.fileService.GetStorageLocation(file)
+	}
+	if (file.CreatedByRequestID != "" || file.UploaderIP != "") && isAdminRequest(c) {
+		file.Audit = &models.FileAudit{
+			CreatedByRequestID: file.CreatedByRequestID,
+			UploaderIP:         file.UploaderIP,
+			UploaderUserAgent:  file.UploaderUserAgent,
+		}
+	}
+
+	if tags, err := h.tagService.GetTags(file.ID); err == nil {
+		file.Tags = tags
+	} else {
+		log.Printf("Warning: Failed to load tags for file %s: %v", file.ID, err)
+	}
+
+	if includes(c, "urls") {
+		h.attachLinks(file)
+	}
+
+	// Small files can be embedded so clients don't need a separate download
+	if includes(c, "content") {
+		if response := h.signedLinkResponse(c); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		if response := h.embedContent(file); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+	}
+
+	// Metadata gets a weak validator of its own; signed links expire, so responses carrying them
+	// are never revalidated
+	if h.fileService.IsMetadataETagEnabled() && !(includes(c, "urls") && h.linkService.IsSigningEnabled()) {
+		etag := services.MetadataETag(file, fmt.Sprintf("%s|%t", c.Query("include"), isAdminRequest(c)))
+		c.Set(fiber.HeaderETag, etag)
+		if utils.ETagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	// Return file metadata by default
+	response := httpx.OK("File retrieved successfully", file)
+	return httpx.SendResponse(c, response)
+}
+
+// streamFile streams content stored at path as the body of a file download, holding a
+// concurrent-download slot when limits are enabled. The slot is released when the response
+// stream is closed, whether the transfer completed or the client went away.
+func (h *FileHandler) streamFile(c *fiber.Ctx, file *models.File, path string, inline bool) error {
+	release := func() {}
+	if h.downloadLimiter.IsEnabled() {
+		var ok bool
+		release, ok = h.downloadLimiter.Acquire(file.ID.String())
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(h.downloadLimiter.GetRetryAfter()))
+			response := httpx.ServiceUnavailable("Too many concurrent downloads for this file, please retry later")
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	backend := h.fileService.GetBackend(file.Backend)
+	info, err := backend.Stat(path)
+	if err != nil {
+		release()
+		response := httpx.InternalServerError("Failed to read file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	f, err := backend.Open(path)
+	if err != nil {
+		release()
+		response := httpx.InternalServerError("Failed to open file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if inline {
+		c.Type(file.Extension)
+		c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("inline", file))
+	} else {
+		c.Type(file.Extension)
+		c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("attachment", file))
+	}
+	if contentType := services.ServedContentType(file); contentType != "" {
+		c.Set(fiber.HeaderContentType, contentType)
+	}
+
+	// The stored content (not a variant) is verified against its hash while it streams
+	if path == file.FilePath && h.fileService.IsDownloadVerificationEnabled() {
+		return h.sendVerifiedStream(c, file, f, release)
+	}
+	return c.SendStream(utils.NewReleasingReadCloser(f, release), int(info.Size()))
+}
+
+// sendStoredFile sends content stored at path in the file's backend. Content on the local
+// filesystem goes through the file sender, which also answers range requests; other backends
+// stream the whole body.
+func (h *FileHandler) sendStoredFile(c *fiber.Ctx, file *models.File, path string) error {
+	src, err := h.fileService.GetBackend(file.Backend).Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			response := httpx.NotFound("File not found on disk")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to open file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if local, ok := src.(*os.File); ok {
+		name := local.Name()
+		local.Close()
+		return c.SendFile(name)
+	}
+	return c.SendStream(src)
+}
+
+// IntegrityTrailer is the response trailer reporting the outcome of streaming hash verification
+const IntegrityTrailer = "X-Integrity-Check"
+
+// sendVerifiedStream streams content while hashing it, reporting in a trailer whether it matched the
+// stored hash. Trailers require a chunked body, so no Content-Length is sent.
+func (h *FileHandler) sendVerifiedStream(c *fiber.Ctx, file *models.File, content io.ReadCloser, release func()) error {
+	// The body is written after the handler returns and its context is recycled, so the trailer is
+	// set through the underlying response, which lives until the body is sent
+	header := &c.Response().Header
+	if err := header.SetTrailer(IntegrityTrailer); err != nil {
+		log.Printf("Warning: Failed to declare integrity trailer: %v", err)
+	}
+
+	verified := h.fileService.NewVerifyingReader(content, file, func(ok bool) {
+		if ok {
+			header.Set(IntegrityTrailer, "passed")
+		} else {
+			header.Set(IntegrityTrailer, "failed")
+		}
+	})
+	return c.SendStream(utils.NewReleasingReadCloser(verified, release), -1)
+}
+
+// sendTranscodedDownload streams a text file converted to the requested charset
+func (h *FileHandler) sendTranscodedDownload(c *fiber.Ctx, file *models.File, charset string) error {
+	if !services.IsTextFile(file) {
+		response := httpx.UnsupportedMediaType("Charset conversion is only available for text files")
+		return httpx.SendResponse(c, response)
+	}
+
+	release := func() {}
+	if h.downloadLimiter.IsEnabled() {
+		var ok bool
+		release, ok = h.downloadLimiter.Acquire(file.ID.String())
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(h.downloadLimiter.GetRetryAfter()))
+			response := httpx.ServiceUnavailable("Too many concurrent downloads for this file, please retry later")
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	transcoded, err := h.fileService.OpenTranscoded(file, charset)
+	if err != nil {
+		release()
+		response := httpx.BadRequest("Failed to convert file charset", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	contentType := file.MimeType
+	if file.ContentTypeOverride != "" {
+		contentType = file.ContentTypeOverride
+	}
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+
+	c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("attachment", file))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentType, contentType+"; charset="+transcoded.Charset)
+
+	// The converted length isn't known up front, so the body is streamed without Content-Length
+	return c.SendStream(utils.NewReleasingReadCloser(transcoded, release))
+}
+
+// GetRawFile streams the exact stored bytes of a file as application/octet-stream, bypassing
+// gzip variants, charset conversion and response compression. Admins may also fetch files whose
+// status or access window blocks regular downloads, e.g. to re-scan or back them up.
+func (h *FileHandler) GetRawFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if !isAdminRequest(c) {
+		if response := blockedStatusResponse(file.Status); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		if response := accessWindowResponse(&file); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+	}
+
+	release := func() {}
+	if h.downloadLimiter.IsEnabled() {
+		var ok bool
+		release, ok = h.downloadLimiter.Acquire(file.ID.String())
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(h.downloadLimiter.GetRetryAfter()))
+			response := httpx.ServiceUnavailable("Too many concurrent downloads for this file, please retry later")
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	src, err := h.fileService.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		release()
+		if os.IsNotExist(err) {
+			response := httpx.NotFound("File not found on disk")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to open file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if digest, ok := services.ContentDigest(&file); ok {
+		c.Set("Digest", digest)
+	}
+	c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("attachment", &file))
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderCacheControl, "no-transform")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(utils.NewReleasingReadCloser(src, release), int(file.FileSize))
+}
+
+// GetOriginalFile downloads the untouched original kept for a transformed file
+func (h *FileHandler) GetOriginalFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if file.OriginalFilePath == "" {
+		response := httpx.NotFound("No original is kept for this file")
+		return httpx.SendResponse(c, response)
+	}
+
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Originals are subject to the same verdicts as the processed file
+	if response := blockedStatusResponse(file.Status); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := accessWindowResponse(&file); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	if _, err := h.fileService.GetBackend(file.Backend).Stat(file.OriginalFilePath); os.IsNotExist(err) {
+		response := httpx.NotFound("Original file not found on disk")
+		return httpx.SendResponse(c, response)
+	}
+
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentDisposition, h.fileService.ContentDisposition("attachment", &file))
+	return h.sendStoredFile(c, &file, file.OriginalFilePath)
+}
+
+// GetFilePreview serves a rendered first-page preview of a PDF file
+func (h *FileHandler) GetFilePreview(c *fiber.Ctx) error {
+	if !h.previewService.IsEnabled() {
+		response := httpx.NotFound("Previews are not enabled")
+		return httpx.SendResponse(c, response)
+	}
+	if !h.previewService.IsAvailable() {
+		response := httpx.ServiceUnavailable("Preview rendering is not available")
+		return httpx.SendResponse(c, response)
+	}
+
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if !h.previewService.SupportsFile(&file) {
+		response := httpx.UnsupportedMediaType("Previews are only available for PDF files")
+		return httpx.SendResponse(c, response)
+	}
+
+	// Previews are rendered from the content, so they are subject to the same checks as downloads
+	if response := blockedStatusResponse(file.Status); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := accessWindowResponse(&file); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	// Check if file exists on disk
+	if _, err := h.fileService.GetBackend(file.Backend).Stat(file.FilePath); os.IsNotExist(err) {
+		response := httpx.NotFound("File not found on disk")
+		return httpx.SendResponse(c, response)
+	}
+
+	previewPath, err := h.previewService.EnsurePreview(&file)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to render preview", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	c.Type("png")
+	return c.SendFile(previewPath)
+}
+
+// GetFileThumbnail serves the JPEG thumbnail of an image file, generating it on first request
+func (h *FileHandler) GetFileThumbnail(c *fiber.Ctx) error {
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if !h.thumbnailService.SupportsFile(&file) {
+		response := httpx.UnsupportedMediaType("Thumbnails are not supported for this file type")
+		return httpx.SendResponse(c, response)
+	}
+
+	// Thumbnails are rendered from the content, so they are subject to the same checks as downloads
+	if response := blockedStatusResponse(file.Status); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := accessWindowResponse(&file); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	thumbnailPath, err := h.thumbnailService.EnsureThumbnail(&file)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to generate thumbnail", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	c.Type("jpg")
+	return c.SendFile(thumbnailPath)
+}
+
+// GetFilePathHistory returns the physical locations a file's content has been stored at, oldest first
+func (h *FileHandler) GetFilePathHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	history, err := services.GetFilePathHistory(&file)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to fetch path history", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Path history retrieved successfully", history)
+	return httpx.SendResponse(c, response)
+}
+
+// GetFileChecksum returns the digest of a file's stored content for the algorithm requested with
+// ?algo=, defaulting to the algorithm the file was hashed with
+func (h *FileHandler) GetFileChecksum(c *fiber.Ctx) error {
+	algorithm, digest, cached, errResponse := h.resolveFileDigest(c)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	response := httpx.OK("File checksum computed", fiber.Map{
+		"algorithm": algorithm,
+		"digest":    digest,
+		"cached":    cached,
+	})
+	return httpx.SendResponse(c, response)
+}
+
+// VerifyFileHash compares a digest of a file's stored content to a client-provided value.
+// The stored hash is deliberately not trusted, so the digest is computed from disk the first time
+// it is requested for each algorithm; repeated checks are served from the digest cache.
+func (h *FileHandler) VerifyFileHash(c *fiber.Ctx) error {
+	expected := strings.ToLower(strings.TrimSpace(c.Query("hash")))
+	if expected == "" {
+		response := httpx.BadRequest("Query parameter 'hash' is required", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	algorithm, actual, cached, errResponse := h.resolveFileDigest(c)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	response := httpx.OK("File hash verified", fiber.Map{
+		"algorithm": algorithm,
+		"matches":   subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1,
+		"cached":    cached,
+	})
+	return httpx.SendResponse(c, response)
+}
+
+// resolveFileDigest loads the file named by the route and its digest for the algorithm requested
+// with ?algo=. It returns the algorithm, the digest and whether it came from the digest cache, or
+// the error response to send.
+func (h *FileHandler) resolveFileDigest(c *fiber.Ctx) (string, string, bool, *httpx.Response) {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return "", "", false, &response
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return "", "", false, &response
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return "", "", false, &response
+	}
+
+	// Default to the algorithm the file was hashed with
+	algorithm := utils.NormalizeHashAlgorithm(c.Query("algo", file.HashAlgorithm))
+	if !utils.IsSupportedHashAlgorithm(algorithm) {
+		response := httpx.BadRequest(fmt.Sprintf("Unsupported hash algorithm; supported: %s", strings.Join(utils.SupportedHashAlgorithms, ", ")), nil)
+		return "", "", false, &response
+	}
+
+	if _, err := h.fileService.GetBackend(file.Backend).Stat(file.FilePath); os.IsNotExist(err) {
+		response := httpx.NotFound("File not found on disk")
+		return "", "", false, &response
+	}
+
+	digest, cached, err := h.fileService.GetFileDigest(&file, algorithm)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to calculate file hash", err)
+		return "", "", false, &response
+	}
+	return algorithm, digest, cached, nil
+}
+
+// similarCandidatePageSize is how many perceptual hashes a similarity search reads per query
+const similarCandidatePageSize = 1000
+
+// GetSimilarFiles returns the images most visually similar to the given file, closest first
+func (h *FileHandler) GetSimilarFiles(c *fiber.Ctx) error {
+	if !h.fileService.IsPerceptualHashEnabled() {
+		response := httpx.NotFound("Similarity search is not enabled")
+		return httpx.SendResponse(c, response)
+	}
+
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if file.PerceptualHash == "" {
+		response := httpx.UnsupportedMediaType("File has no perceptual hash; similarity search is only available for images")
+		return httpx.SendResponse(c, response)
+	}
+
+	targetHash, err := utils.ParsePerceptualHash(file.PerceptualHash)
+	if err != nil {
+		response := httpx.InternalServerError("Invalid stored perceptual hash", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	threshold := c.QueryInt("threshold", h.fileService.GetSimilarityThreshold())
+	if threshold < 0 || threshold > 64 {
+		response := httpx.BadRequest("Threshold must be between 0 and 64", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit <= 0 {
+		response := httpx.BadRequest("Limit must be a positive number", nil)
+		return httpx.SendResponse(c, response)
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	type match struct {
+		id       uuid.UUID
+		distance int
+	}
+
+	// Candidates are compared a page at a time, reading only their hashes, and only the closest
+	// matches are kept, so memory stays bounded however many images are stored
+	var matches []match
+	var lastID uuid.UUID
+	for {
+		var page []models.File
+		query := database.DB.Select("id", "perceptual_hash").
+			Where("perceptual_hash <> '' AND id <> ?", file.ID).
+			Order("id ASC").Limit(similarCandidatePageSize)
+		if lastID != uuid.Nil {
+			query = query.Where("id > ?", lastID)
+		}
+		if err := query.Find(&page).Error; err != nil {
+			response := httpx.InternalServerError("Failed to fetch files", err)
+			return httpx.SendResponse(c, response)
+		}
+
+		for _, candidate := range page {
+			candidateHash, err := utils.ParsePerceptualHash(candidate.PerceptualHash)
+			if err != nil {
+				continue
+			}
+			if distance := utils.HammingDistance(targetHash, candidateHash); distance <= threshold {
+				matches = append(matches, match{id: candidate.ID, distance: distance})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].distance < matches[j].distance
+		})
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		if len(page) < similarCandidatePageSize {
+			break
+		}
+		lastID = page[len(page)-1].ID
+	}
+
+	type similarFile struct {
+		models.File
+		Distance int `json:"distance"`
+	}
+
+	similar := []similarFile{}
+	if len(matches) > 0 {
+		ids := make([]uuid.UUID, len(matches))
+		for i, m := range matches {
+			ids[i] = m.id
+		}
+		var files []models.File
+		if err := database.DB.Where("id IN ?", ids).Find(&files).Error; err != nil {
+			response := httpx.InternalServerError("Failed to fetch files", err)
+			return httpx.SendResponse(c, response)
+		}
+		byID := make(map[uuid.UUID]models.File, len(files))
+		for _, f := range files {
+			byID[f.ID] = f
+		}
+		for _, m := range matches {
+			if f, ok := byID[m.id]; ok {
+				similar = append(similar, similarFile{File: f, Distance: m.distance})
+			}
+		}
+	}
+
+	result := map[string]interface{}{
+		"files":     similar,
+		"threshold": threshold,
+		"limit":     limit,
+	}
+
+	response := httpx.OK("Similar files retrieved successfully", result)
+	return httpx.SendResponse(c, response)
+}
+
+// ListExternalReferences lists the external references registered for a file
+func (h *FileHandler) ListExternalReferences(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if err := database.DB.Select("id").First(&models.File{}, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	references, err := h.fileReferences.ListReferences(fileID)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to fetch external references", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	result := map[string]interface{}{
+		"references":        references,
+		"total":             len(references),
+		"deletionProtected": h.fileReferences.IsDeletionProtected() && len(references) > 0,
+	}
+
+	response := httpx.OK("External references retrieved successfully", result)
+	return httpx.SendResponse(c, response)
+}
+
+// AddExternalReference registers an external system's use of a file. Registering the same
+// consumer and reference again is a no-op that returns the existing reference.
+func (h *FileHandler) AddExternalReference(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var input requests.FileReferenceRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	input.Consumer = strings.TrimSpace(input.Consumer)
+	input.Reference = strings.TrimSpace(input.Reference)
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	reference, created, err := h.fileReferences.AddReference(fileID, input.Consumer, input.Reference)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to add external reference", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if !created {
+		response := httpx.OK("External reference already exists", reference)
+		return httpx.SendResponse(c, response)
+	}
+	response := httpx.Created("External reference added successfully", reference)
+	return httpx.SendResponse(c, response)
+}
+
+// RemoveExternalReference removes an external reference given by the consumer and reference query
+// parameters. Once a file has no references left it can be deleted again.
+func (h *FileHandler) RemoveExternalReference(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	input := requests.FileReferenceRequest{
+		Consumer:  strings.TrimSpace(c.Query("consumer")),
+		Reference: strings.TrimSpace(c.Query("reference")),
+	}
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	removed, err := h.fileReferences.RemoveReference(fileID, input.Consumer, input.Reference)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to remove external reference", err)
+		return httpx.SendResponse(c, response)
+	}
+	if !removed {
+		response := httpx.NotFound("External reference not found")
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("External reference removed successfully", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// maxBulkTagFiles is the maximum number of files a single bulk tag request may update
+const maxBulkTagFiles = 100
+
+// BulkTagFiles adds and removes tags across multiple files in one transaction
+func (h *FileHandler) BulkTagFiles(c *fiber.Ctx) error {
+	var input requests.BulkTagRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if len(input.IDs) == 0 {
+		response := httpx.BadRequest("No file IDs provided", nil)
+		return httpx.SendResponse(c, response)
+	}
+	if len(input.IDs) > maxBulkTagFiles {
+		response := httpx.BadRequest(fmt.Sprintf("Maximum %d files allowed per request", maxBulkTagFiles), nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	add, err := h.tagService.NormalizeTags(input.Add)
+	if err != nil {
+		response := httpx.BadRequest("Invalid tags", err)
+		return httpx.SendResponse(c, response)
+	}
+	remove, err := h.tagService.NormalizeTags(input.Remove)
+	if err != nil {
+		response := httpx.BadRequest("Invalid tags", err)
+		return httpx.SendResponse(c, response)
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		response := httpx.BadRequest("No tags to add or remove", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	fileIDs := make([]uuid.UUID, 0, len(input.IDs))
+	for _, id := range input.IDs {
+		fileID, err := utils.ParseID(id)
+		if err != nil {
+			response := httpx.BadRequest(fmt.Sprintf("Invalid file ID: %s", id), err)
+			return httpx.SendResponse(c, response)
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+
+	results, err := h.tagService.BulkUpdateTags(fileIDs, add, remove)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to update tags", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	successful := 0
+	for _, result := range results {
+		if result.Success {
+			successful++
+		}
+	}
+
+	responseData := map[string]interface{}{
+		"results":    results,
+		"successful": successful,
+		"failed":     len(results) - successful,
+	}
+
+	response := httpx.OK("Tags updated", responseData)
+	return httpx.SendResponse(c, response)
+}
+
+// GetFolderArchive streams a gzip-compressed tar of all files under a folder prefix. Archives are
+// subject to the same link and referer checks as downloads, and only admins may archive the whole
+// store by leaving the prefix empty.
+func (h *FileHandler) GetFolderArchive(c *fiber.Ctx) error {
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	prefix, err := h.folderService.NormalizePath(c.Query("prefix"))
+	if err != nil {
+		response := httpx.BadRequest("Invalid prefix", err)
+		return httpx.SendResponse(c, response)
+	}
+	if prefix == "" && !isAdminRequest(c) {
+		response := httpx.BadRequest("A folder prefix is required", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	archiveName := "files.tar.gz"
+	if prefix != "" {
+		archiveName = strings.ReplaceAll(prefix, "/", "_") + ".tar.gz"
+	}
+
+	c.Set(fiber.HeaderContentType, "application/gzip")
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": archiveName}))
+
+	// The archive is written as it is sent; errors after streaming starts can only end the response early
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.fileService.WriteFolderArchive(w, prefix); err != nil {
+			log.Printf("Failed to stream archive for prefix %q: %v", prefix, err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// GetThumbnails returns a zip archive with thumbnails for the requested image files
+func (h *FileHandler) GetThumbnails(c *fiber.Ctx) error {
+	var input requests.ThumbnailBatchRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Thumbnails are content, so the batch is subject to the same checks as a single thumbnail
+	if response := h.signedLinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+	if response := h.hotlinkResponse(c); response != nil {
+		return httpx.SendResponse(c, *response)
+	}
+
+	if len(input.IDs) == 0 {
+		response := httpx.BadRequest("No file IDs provided", nil)
+		return httpx.SendResponse(c, response)
+	}
+	if maxBatch := h.thumbnailService.GetMaxBatch(); len(input.IDs) > maxBatch {
+		response := httpx.BadRequest(fmt.Sprintf("Maximum %d thumbnails allowed per request", maxBatch), nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	fileIDs := make([]uuid.UUID, 0, len(input.IDs))
+	for _, id := range input.IDs {
+		fileID, err := utils.ParseID(id)
+		if err != nil {
+			response := httpx.BadRequest(fmt.Sprintf("Invalid file ID: %s", id), err)
+			return httpx.SendResponse(c, response)
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+
+	var files []models.File
+	if err := database.DB.Where("id IN ?", fileIDs).Find(&files).Error; err != nil {
+		response := httpx.InternalServerError("Failed to fetch files", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	filesByID := make(map[uuid.UUID]models.File, len(files))
+	for _, file := range files {
+		filesByID[file.ID] = file
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="thumbnails.zip"`)
+
+	// The archive is written as it is sent; errors after streaming starts can only end the response early
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.writeThumbnailArchive(w, fileIDs, filesByID); err != nil {
+			log.Printf("Failed to stream thumbnail archive: %v", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// writeThumbnailArchive writes a zip with one entry per thumbnail and a manifest describing every
+// requested ID; files that can't be served get an error in the manifest instead of an entry
+func (h *FileHandler) writeThumbnailArchive(w io.Writer, fileIDs []uuid.UUID, filesByID map[uuid.UUID]models.File) error {
+	archive := zip.NewWriter(w)
+	manifest := make([]map[string]interface{}, 0, len(fileIDs))
+
+	for _, fileID := range fileIDs {
+		entry := map[string]interface{}{"id": fileID.String()}
+		manifest = append(manifest, entry)
+
+		file, ok := filesByID[fileID]
+		if !ok {
+			entry["error"] = "File not found"
+			continue
+		}
+		if !h.thumbnailService.SupportsFile(&file) {
+			entry["error"] = "Thumbnails are not supported for this file type"
+			continue
+		}
+		if response := blockedStatusResponse(file.Status); response != nil {
+			entry["error"] = response.Message
+			continue
+		}
+		if response := accessWindowResponse(&file); response != nil {
+			entry["error"] = response.Message
+			continue
+		}
+
+		src, err := h.thumbnailService.OpenThumbnail(&file)
+		if err != nil {
+			entry["error"] = err.Error()
+			continue
+		}
+
+		name := fileID.String() + ".jpg"
+		err = addToZip(archive, name, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+		entry["thumbnail"] = name
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := addToZip(archive, "manifest.json", bytes.NewReader(manifestData)); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// addToZip copies content into a zip archive under the given name
+func addToZip(archive *zip.Writer, name string, src io.Reader) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, src)
+	return err
+}
+
+// assignableStatuses lists the statuses clients may set through metadata updates
+var assignableStatuses = map[string]bool{
+	"active":   true,
+	"inactive": true,
+	"archived": true,
+	"deleted":  true,
+}
+
+// ReplaceFile replaces a file's metadata (PUT). Optional fields that are omitted are reset
+// to their defaults; use UpdateFile (PATCH) to change individual fields.
+func (h *FileHandler) ReplaceFile(c *fiber.Ctx) error {
+	var input requests.ReplaceFileRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+	if strings.TrimSpace(input.FileName) == "" {
+		response := httpx.BadRequest("Validation failed", errors.New("fileName is required"))
+		return httpx.SendResponse(c, response)
+	}
+
+	contentTypeOverride := ""
+	if input.ContentTypeOverride != nil {
+		contentTypeOverride = *input.ContentTypeOverride
+	}
+
+	originalName, rawOriginalName := h.fileService.NormalizeOriginalName(input.FileName)
+
+	updates := map[string]interface{}{
+		"original_name":         originalName,
+		"raw_original_name":     rawOriginalName,
+		"content_type_override": contentTypeOverride,
+		"accessible_from":       input.AccessibleFrom,
+		"accessible_until":      input.AccessibleUntil,
+	}
+
+	// Status is lifecycle state rather than descriptive metadata, so it is kept unless given
+	if input.Status != nil {
+		updates["status"] = *input.Status
+	}
+
+	return h.applyFileUpdates(c, updates, input.Status != nil)
+}
+
+// UpdateFile partially updates a file's metadata (PATCH). Only the provided fields are changed.
+func (h *FileHandler) UpdateFile(c *fiber.Ctx) error {
+	var input requests.UpdateFileRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Update fields
+	updates := make(map[string]interface{})
+	if input.FileName != nil {
+		originalName, rawOriginalName := h.fileService.NormalizeOriginalName(*input.FileName)
+		updates["original_name"] = originalName
+		updates["raw_original_name"] = rawOriginalName
+	}
+	if input.Status != nil {
+		updates["status"] = *input.Status
+	}
+	if input.ContentTypeOverride != nil {
+		updates["content_type_override"] = *input.ContentTypeOverride
+	}
+	if input.AccessibleFrom != nil {
+		updates["accessible_from"] = input.AccessibleFrom
+	}
+	if input.AccessibleUntil != nil {
+		updates["accessible_until"] = input.AccessibleUntil
+	}
+
+	return h.applyFileUpdates(c, updates, input.Status != nil)
+}
+
+// applyFileUpdates loads the file from the route and applies the given column updates.
+// statusRequested marks an explicit status change, which is refused while a file is held by virus
+// scanning or marked corrupted by integrity verification.
+func (h *FileHandler) applyFileUpdates(c *fiber.Ctx, updates map[string]interface{}, statusRequested bool) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if status, ok := updates["status"].(string); ok && !assignableStatuses[status] {
+		response := httpx.BadRequest("Validation failed", fmt.Errorf("invalid status: %s", status))
+		return httpx.SendResponse(c, response)
+	}
+
+	// An empty override clears it; anything else must be a valid media type
+	if override, ok := updates["content_type_override"].(string); ok && override != "" {
+		mediaType, params, err := mime.ParseMediaType(override)
+		if err != nil {
+			response := httpx.BadRequest("Invalid content type override", err)
+			return httpx.SendResponse(c, response)
+		}
+		updates["content_type_override"] = mime.FormatMediaType(mediaType, params)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Files pending or failing a virus scan keep their status until the scanner decides, and
+	// corrupted files until integrity verification does
+	controller := statusController(file.Status)
+	if file.Status == "corrupted" && isAdminRequest(c) {
+		// Corrupted files are never verified again, so an admin who restored the content clears it
+		controller = ""
+	}
+	if controller != "" {
+		if statusRequested {
+			response := httpx.Conflict("File status is controlled by "+controller, errors.New(file.Status))
+			return httpx.SendResponse(c, response)
+		}
+		delete(updates, "status")
+	}
+
+	// Renames must not collide with another file in the same folder
+	if name, ok := updates["original_name"].(string); ok && name != file.OriginalName && h.folderService.IsUniqueNamesEnabled() {
+		taken, err := h.folderService.FindTakenNames(file.Folder, []string{name}, file.ID)
+		if err != nil {
+			response := httpx.InternalServerError("Failed to check file names", err)
+			return httpx.SendResponse(c, response)
+		}
+		if len(taken) > 0 {
+			response := httpx.Conflict("A file with the same name already exists in the folder", fmt.Errorf("name already taken: %s", name))
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	// The access window must stay ordered once combined with the values not being changed
+	accessibleFrom, accessibleUntil := file.AccessibleFrom, file.AccessibleUntil
+	if value, ok := updates["accessible_from"].(*time.Time); ok {
+		accessibleFrom = value
+	}
+	if value, ok := updates["accessible_until"].(*time.Time); ok {
+		accessibleUntil = value
+	}
+	if accessibleFrom != nil && accessibleUntil != nil && !accessibleFrom.Before(*accessibleUntil) {
+		response := httpx.BadRequest("Validation failed", errors.New("accessibleFrom must be before accessibleUntil"))
+		return httpx.SendResponse(c, response)
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&file).Updates(updates).Error; err != nil {
+			response := httpx.InternalServerError("Failed to update file", err)
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	response := httpx.OK("File updated successfully", file)
+	return httpx.SendResponse(c, response)
+}
+
+// DeleteFile deletes a file
+func (h *FileHandler) DeleteFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	fileID, err := utils.ParseID(id)
+	if err != nil {
+		response := httpx.BadRequest("Invalid file ID", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var file models.File
+	if err := database.DB.First(&file, fileID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response := httpx.NotFound("File not found")
+			return httpx.SendResponse(c, response)
+		}
+		response := httpx.InternalServerError("Failed to fetch file", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// With If-Match, only the expected version of the file is deleted
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" && !matchesFileVersion(ifMatch, &file) {
+		response := httpx.PreconditionFailed("File does not match the If-Match precondition")
+		return httpx.SendResponse(c, response)
+	}
+
+	// Delete file record, unless external references protect it
+	references, err := h.fileReferences.DeleteFileRecord(&file)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to delete file", err)
+		return httpx.SendResponse(c, response)
+	}
+	if references > 0 {
+		response := httpx.Conflict("File is referenced by other systems and can't be deleted", nil)
+		response.Data = map[string]interface{}{
+			"references": references,
+		}
+		return httpx.SendResponse(c, response)
+	}
+
+	// Delete file (and the kept original of a converted file) from the backend holding it
+	if err := h.fileService.DeleteStoredFile(file.Backend, file.FilePath, file.OriginalFilePath); err != nil {
+		log.Printf("Warning: Failed to delete file from storage: %v", err)
+	}
+
+	// Delete precompressed variant if any
+	if err := h.fileService.DeleteGzipVariant(&file); err != nil {
+		log.Printf("Warning: Failed to delete gzip variant from disk: %v", err)
+	}
+
+	// Delete rendered preview if any
+	if err := h.previewService.DeletePreview(&file); err != nil {
+		log.Printf("Warning: Failed to delete preview from disk: %v", err)
+	}
+
+	// Delete tags
+	if err := h.tagService.DeleteTags(file.ID); err != nil {
+		log.Printf("Warning: Failed to delete tags: %v", err)
+	}
+
+	// Delete external references left over from before protection was enabled
+	if err := h.fileReferences.DeleteReferences(file.ID); err != nil {
+		log.Printf("Warning: Failed to delete external references: %v", err)
+	}
+
+	// Delete cached digests
+	if err := h.fileService.DeleteFileDigests(file.ID); err != nil {
+		log.Printf("Warning: Failed to delete cached digests: %v", err)
+	}
+
+	// Delete indexed document text if any
+	if h.contentIndex.IsEnabled() {
+		if err := h.contentIndex.DeleteContent(&file); err != nil {
+			log.Printf("Warning: Failed to delete indexed content: %v", err)
+		}
+	}
+
+	// Delete generated thumbnail if any
+	if err := h.thumbnailService.DeleteThumbnail(&file); err != nil {
+		log.Printf("Warning: Failed to delete thumbnail from disk: %v", err)
+	}
+
+	response := httpx.OK("File deleted successfully", nil)
+	return httpx.SendResponse(c, response)
+}
+
+// matchesFileVersion reports whether an If-Match header names the file's current content, either
+// by its content ETag or by its hash, quoted or bare
+func matchesFileVersion(ifMatch string, file *models.File) bool {
+	if utils.ETagMatches(ifMatch, services.ContentETag(file)) || utils.ETagMatches(ifMatch, `"`+file.Hash+`"`) {
+		return true
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.EqualFold(strings.TrimSpace(candidate), file.Hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRecentFiles returns the most recently uploaded files, newest first
+func (h *FileHandler) GetRecentFiles(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		response := httpx.BadRequest("Limit must be a positive number", nil)
+		return httpx.SendResponse(c, response)
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var files []models.File
+	if err := database.DB.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&files).Error; err != nil {
+		response := httpx.InternalServerError("Failed to fetch files", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Recent files retrieved successfully", files)
+	return httpx.SendResponse(c, response)
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input matches literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// sortableColumns lists the columns search results may be ordered by
+var sortableColumns = map[string]bool{
+	"created_at":    true,
+	"updated_at":    true,
+	"original_name": true,
+	"file_size":     true,
+}
+
+// projectableFields maps the file fields search results may be narrowed to with ?fields= to their
+// columns. Tags are loaded separately and have no column.
+var projectableFields = map[string]string{
+	"id":                  "id",
+	"originalName":        "original_name",
+	"folder":              "folder",
+	"storedName":          "stored_name",
+	"fileSize":            "file_size",
+	"mimeType":            "mime_type",
+	"contentTypeOverride": "content_type_override",
+	"extension":           "extension",
+	"fileType":            "file_type",
+	"hash":                "hash",
+	"hashAlgorithm":       "hash_algorithm",
+	"status":              "status",
+	"hasGzipVariant":      "has_gzip_variant",
+	"accessibleFrom":      "accessible_from",
+	"accessibleUntil":     "accessible_until",
+	"createdAt":           "created_at",
+	"updatedAt":           "updated_at",
+	"tags":                "",
+}
+
+// parseProjection validates a comma-separated ?fields= list against projectableFields and returns
+// the requested fields in order, without duplicates
+func parseProjection(raw string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := projectableFields[field]; !ok {
+			return nil, fmt.Errorf("unsupported field: %s", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+	return fields, nil
+}
+
+// projectionColumns returns the columns to read for a projection. The ID is always read because
+// tags and links are looked up by it, and links also depend on the file's type and kept original.
+func projectionColumns(fields []string, withLinks bool) []string {
+	columns := []string{"id"}
+	for _, field := range fields {
+		if column := projectableFields[field]; column != "" && column != "id" {
+			columns = append(columns, column)
+		}
+	}
+	if withLinks {
+		columns = append(columns, "extension", "mime_type", "original_file_path")
+	}
+	return columns
+}
+
+// projectFiles reduces files to the requested fields, plus links when they were requested
+func projectFiles(files []models.File, fields []string, withLinks bool) ([]map[string]interface{}, error) {
+	keys := fields
+	if withLinks {
+		keys = append(append([]string{}, fields...), "links")
+	}
+
+	projected := make([]map[string]interface{}, 0, len(files))
+	for i := range files {
+		encoded, err := json.Marshal(&files[i])
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(encoded, &full); err != nil {
+			return nil, err
+		}
+
+		item := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			// Omitted (empty) values are reported as null so every requested field is present
+			item[key] = full[key]
+		}
+		projected = append(projected, item)
+	}
+	return projected, nil
+}
+
+// SearchFiles searches for files based on criteria
+func (h *FileHandler) SearchFiles(c *fiber.Ctx) error {
+	var input requests.FileSearchRequest
+	if err := c.QueryParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid query parameters", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// The page size bounds are configured per deployment
+	searchConfig := h.fileService.GetSearchConfig()
+	if input.Limit > searchConfig.GetMaxLimit() {
+		response := httpx.BadRequest(fmt.Sprintf("limit must not exceed %d", searchConfig.GetMaxLimit()), nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Set defaults
+	if input.Page <= 0 {
+		input.Page = 1
+	}
+	if input.Limit <= 0 {
+		input.Limit = searchConfig.GetDefaultLimit()
+	}
+	if input.SortBy == "" {
+		input.SortBy = searchConfig.DefaultSortBy
+	}
+	if input.SortBy == "" {
+		input.SortBy = "created_at"
+	}
+	if input.SortOrder == "" {
+		input.SortOrder = searchConfig.DefaultSortOrder
+	}
+	if input.SortOrder == "" {
+		input.SortOrder = "desc"
+	}
+
+	// Sort values are interpolated into ORDER BY, so only whitelisted values are accepted
+	if !sortableColumns[input.SortBy] {
+		response := httpx.BadRequest("Invalid sortBy value", fmt.Errorf("unsupported sort column: %s", input.SortBy))
+		return httpx.SendResponse(c, response)
+	}
+	input.SortOrder = strings.ToLower(input.SortOrder)
+	if input.SortOrder != "asc" && input.SortOrder != "desc" {
+		response := httpx.BadRequest("Invalid sortOrder value", fmt.Errorf("unsupported sort order: %s", input.SortOrder))
+		return httpx.SendResponse(c, response)
+	}
+
+	// A projection narrows both the columns read and the fields returned
+	var fields []string
+	if input.Fields != "" {
+		var err error
+		if fields, err = parseProjection(input.Fields); err != nil {
+			response := httpx.BadRequest("Invalid fields value", err)
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	query, errResponse := h.searchQuery(c, &input)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		response := httpx.InternalServerError("Failed to count files", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	withLinks := includes(c, "urls")
+	withTags := fields == nil || slices.Contains(fields, "tags")
+
+	// Polling clients revalidate with If-None-Match. Signed links expire, so pages carrying them are
+	// always sent in full.
+	if !withLinks || !h.linkService.IsSigningEnabled() {
+		fingerprint, err := searchFingerprint(query, total, withTags)
+		if err != nil {
+			response := httpx.InternalServerError("Failed to fetch files", err)
+			return httpx.SendResponse(c, response)
+		}
+		etag := services.SearchResultsETag(fingerprint, fmt.Sprintf("%s|%t", c.OriginalURL(), isAdminRequest(c)))
+		c.Set(fiber.HeaderETag, etag)
+		if utils.ETagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	// Apply sorting and pagination
+	offset := (input.Page - 1) * input.Limit
+	if input.Content != "" {
+		// Content matches are ranked by relevance ahead of the requested sort
+		query = h.contentIndex.OrderByContentRank(query, input.Content)
+	}
+	// Break ties on id so rows sharing a sort value keep a stable order across pages
+	query = query.Order(input.SortBy + " " + input.SortOrder).
+		Order("id " + input.SortOrder).
+		Offset(offset).
+		Limit(input.Limit)
+
+	if fields != nil {
+		query = query.Select(projectionColumns(fields, withLinks))
+	}
+
+	var files []models.File
+	if err := query.Find(&files).Error; err != nil {
+		response := httpx.InternalServerError("Failed to fetch files", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	if withTags {
+		if err := h.tagService.AttachTags(files); err != nil {
+			log.Printf("Warning: Failed to load tags for search results: %v", err)
+		}
+	}
+
+	if withLinks {
+		for i := range files {
+			h.attachLinks(&files[i])
+		}
+	}
+
+	var results interface{} = files
+	if fields != nil {
+		projected, err := projectFiles(files, fields, withLinks)
+		if err != nil {
+			response := httpx.InternalServerError("Failed to build search results", err)
+			return httpx.SendResponse(c, response)
+		}
+		results = projected
+	}
+
+	// Build response
+	result := map[string]interface{}{
+		"files": results,
+		"pagination": map[string]interface{}{
+			"page":       input.Page,
+			"limit":      input.Limit,
+			"total":      total,
+			"totalPages": (total + int64(input.Limit) - 1) / int64(input.Limit),
+		},
+	}
+
+	response := httpx.OK("Files retrieved successfully", result)
+	return httpx.SendResponse(c, response)
+}
+
+// searchFingerprint summarizes the files matching a search query: their number and latest update
+// and, when tags are returned, the number and latest addition of their tags. Adding, removing or
+// updating a matching file changes it. Tags are tracked separately since tagging doesn't touch the
+// file record.
+func searchFingerprint(query *gorm.DB, total int64, withTags bool) (string, error) {
+	var files struct {
+		UpdatedAt *time.Time
+	}
+	if err := query.Session(&gorm.Session{}).Select("MAX(updated_at) AS updated_at").Scan(&files).Error; err != nil {
+		return "", err
+	}
+	fingerprint := fmt.Sprintf("%d|%s", total, formatFingerprintTime(files.UpdatedAt))
+
+	if withTags {
+		var tags struct {
+			Count     int64
+			CreatedAt *time.Time
+		}
+		if err := database.DB.Model(&models.FileTag{}).
+			Select("COUNT(*) AS count, MAX(created_at) AS created_at").
+			Where("file_id IN (?)", query.Session(&gorm.Session{}).Select("id")).
+			Scan(&tags).Error; err != nil {
+			return "", err
+		}
+		fingerprint += fmt.Sprintf("|%d|%s", tags.Count, formatFingerprintTime(tags.CreatedAt))
+	}
+	return fingerprint, nil
+}
+
+// formatFingerprintTime formats an optional timestamp for a fingerprint
+func formatFingerprintTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// searchQuery validates the filters of a search request and builds the query selecting the
+// matching files, or returns the error response to send
+func (h *FileHandler) searchQuery(c *fiber.Ctx, input *requests.FileSearchRequest) (*gorm.DB, *httpx.Response) {
+	// Hashes are stored as lowercase hex, so any other input can never match
+	input.HashPrefix = strings.ToLower(input.HashPrefix)
+	if input.HashPrefix != "" && !utils.IsHexString(input.HashPrefix) {
+		response := httpx.BadRequest("hashPrefix must be hexadecimal", nil)
+		return nil, &response
+	}
+
+	if input.Content != "" && !h.contentIndex.IsEnabled() {
+		response := httpx.BadRequest("Content search is not enabled", nil)
+		return nil, &response
+	}
+
+	// Uploader addresses are only reported to admins, so only admins may search by them
+	if input.UploaderIP != "" {
+		if !isAdminRequest(c) {
+			response := httpx.Forbidden("Searching by uploader IP requires admin access")
+			return nil, &response
+		}
+		if net.ParseIP(input.UploaderIP) == nil {
+			response := httpx.BadRequest("uploaderIp must be an IP address", nil)
+			return nil, &response
+		}
+	}
+
+	// Build query
+	query := database.DB.Model(&models.File{})
+
+	// Apply filters
+	if input.FileType != "" {
+		query = query.Where("file_type = ?", input.FileType)
+	}
+	if input.Name != "" {
+		// Match against the normalized form so NFD and NFC spellings find the same files
+		name, _ := h.fileService.NormalizeOriginalName(input.Name)
+		query = query.Where("original_name ILIKE ?", "%"+escapeLikePattern(name)+"%")
+	}
+	if input.HashPrefix != "" {
+		query = query.Where("hash LIKE ?", input.HashPrefix+"%")
+	}
+	if input.Tag != "" {
+		query = h.tagService.FilterByTag(query, input.Tag)
+	}
+	if input.UploaderIP != "" {
+		query = query.Where("uploader_ip = ?", input.UploaderIP)
+	}
+	if input.Content != "" {
+		query = h.contentIndex.FilterByContent(query, input.Content)
+	}
+	if input.Folder != nil {
+		query = query.Where("folder = ?", strings.Trim(*input.Folder, "/"))
+	}
+	if input.Status != "" {
+		query = query.Where("status = ?", input.Status)
+	}
+	if input.UploadedAfter != nil {
+		query = query.Where("created_at >= ?", input.UploadedAfter)
+	}
+	if input.UploadedBefore != nil {
+		query = query.Where("created_at <= ?", input.UploadedBefore)
+	}
+
+	return query, nil
+}
+
+// ExportFiles streams every file matching the search filters as NDJSON, one record per line in ID
+// order. A final line carries the cursor with "complete": true. An export that is cut off resumes
+// with ?cursor= set to the ID of the last record received, continuing without repeating records.
+func (h *FileHandler) ExportFiles(c *fiber.Ctx) error {
+	var input requests.FileExportRequest
+	if err := c.QueryParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid query parameters", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	var afterID uuid.UUID
+	if input.Cursor != "" {
+		var err error
+		if afterID, err = utils.ParseID(input.Cursor); err != nil {
+			response := httpx.BadRequest("Invalid cursor", err)
+			return httpx.SendResponse(c, response)
+		}
+	}
+
+	query, errResponse := h.searchQuery(c, &input.FileSearchRequest)
+	if errResponse != nil {
+		return httpx.SendResponse(c, *errResponse)
+	}
+	searchConfig := h.fileService.GetSearchConfig()
+	batchSize := searchConfig.GetExportBatchSize()
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	// Records are written as they are read; errors after streaming starts can only end the export
+	// early, without the completion line, so clients know to resume
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := json.NewEncoder(w)
+		cursor := input.Cursor
+		for {
+			// Keyset pagination keeps each batch cheap and lets a resumed export start exactly after its cursor
+			batch := query.Session(&gorm.Session{}).Order("id ASC").Limit(batchSize)
+			if afterID != uuid.Nil {
+				batch = batch.Where("id > ?", afterID)
+			}
+
+			var files []models.File
+			if err := batch.Find(&files).Error; err != nil {
+				log.Printf("Export stopped after cursor %q: %v", cursor, err)
+				return
+			}
+			if len(files) == 0 {
+				break
+			}
+
+			if err := h.tagService.AttachTags(files); err != nil {
+				log.Printf("Warning: Failed to load tags for exported files: %v", err)
+			}
+			for i := range files {
+				if err := encoder.Encode(&files[i]); err != nil {
+					return
+				}
+				afterID = files[i].ID
+				cursor = files[i].ID.String()
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+
+		encoder.Encode(fiber.Map{"cursor": cursor, "complete": true})
+		w.Flush()
+	})
+	return nil
+}
+
+// GetFileTimeline returns upload counts and total bytes bucketed by day, week or month
+func (h *FileHandler) GetFileTimeline(c *fiber.Ctx) error {
+	var input requests.FileTimelineRequest
+	if err := c.QueryParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid query parameters", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Set defaults
+	if input.Interval == "" {
+		input.Interval = "day"
+	}
+	if input.Interval != "day" && input.Interval != "week" && input.Interval != "month" {
+		response := httpx.BadRequest("Interval must be one of day, week or month", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Build query
+	query := database.DB.Model(&models.File{}).
+		Select("date_trunc(?, created_at) AS period, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS total_bytes", input.Interval)
+
+	// Apply filters
+	if input.FileType != "" {
+		query = query.Where("file_type = ?", input.FileType)
+	}
+	if input.UploadedAfter != nil {
+		query = query.Where("created_at >= ?", input.UploadedAfter)
+	}
+	if input.UploadedBefore != nil {
+		query = query.Where("created_at <= ?", input.UploadedBefore)
+	}
+
+	var buckets []struct {
+		Period     time.Time `json:"period"`
+		Count      int64     `json:"count"`
+		TotalBytes int64     `json:"totalBytes"`
+	}
+	if err := query.Group("period").Order("period ASC").Scan(&buckets).Error; err != nil {
+		response := httpx.InternalServerError("Failed to build timeline", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	result := map[string]interface{}{
+		"interval": input.Interval,
+		"buckets":  buckets,
+	}
+
+	response := httpx.OK("Timeline retrieved successfully", result)
+	return httpx.SendResponse(c, response)
+}
+
+// CheckFile previews the validation verdict for a file name and size without uploading any content
+func (h *FileHandler) CheckFile(c *fiber.Ctx) error {
+	name := filepath.Base(strings.TrimSpace(c.Query("name")))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		response := httpx.BadRequest("Query parameter 'name' is required", nil)
+		return httpx.SendResponse(c, response)
+	}
+
+	size, err := strconv.ParseInt(c.Query("size"), 10, 64)
+	if err != nil || size < 0 {
+		response := httpx.BadRequest("Query parameter 'size' must be a non-negative number of bytes", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	validationResult := h.fileService.ValidateFileType(name, size)
+	result := map[string]interface{}{
+		"name":     name,
+		"size":     size,
+		"allowed":  validationResult.IsAllowed,
+		"rule":     validationResult.RuleName,
+		"max_size": validationResult.MaxSize,
+	}
+	if !validationResult.IsAllowed {
+		result["code"] = validationResult.Code
+		result["reason"] = validationResult.Reason
+	}
+
+	response := httpx.OK("File check completed", result)
+	return httpx.SendResponse(c, response)
+}
+
+// GetFileLimits returns file size limits for different extensions
+func (h *FileHandler) GetFileLimits(c *fiber.Ctx) error {
 	uploadConfig := h.fileService.GetUploadConfig()
 
+	// Per-extension details come from the validation rules, resolved as they are for uploads
+	validationConfig := h.fileService.GetValidationConfig()
+	details := h.fileService.GetExtensionDetails()
+	extensions := make(map[string]int64, len(details))
+	for _, detail := range details {
+		extensions[detail.Extension] = detail.MaxSize
+	}
+
 	limits := map[string]interface{}{
-		"default_max_size": h.fileService.GetMaxFileSizeForExtension(""),
-		"extensions":       make(map[string]int64),
+		"default_max_size": validationConfig.GetDefaultMaxFileSize(),
+		"extensions":       extensions,
+		"extension_rules":  details,
 		"upload_limits": map[string]interface{}{
 			"max_files":      uploadConfig.MaxFiles,
 			"max_total_size": uploadConfig.MaxTotalSize,
 		},
 	}
 
-	// Get limits for each allowed extension
-	for _, ext := range []string{"jpg", "jpeg", "png", "gif", "pdf", "doc", "docx", "xls", "xlsx", "txt", "csv", "zip", "rar", "mp4", "avi", "mov", "mp3", "wav"} {
-		limits["extensions"].(map[string]int64)[ext] = h.fileService.GetMaxFileSizeForExtension(ext)
-	}
-
 	response := httpx.OK("File limits retrieved successfully", limits)
 	return httpx.SendResponse(c, response)
 }
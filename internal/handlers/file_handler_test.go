@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/models"
+	"storage-api/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-utils/httpx"
+)
+
+func TestBlockedStatusResponse(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantStatus int
+	}{
+		{"quarantined", http.StatusLocked},
+		{"infected", http.StatusForbidden},
+		{"corrupted", http.StatusConflict},
+		{"active", 0},
+		{"inactive", 0},
+		{"archived", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			response := blockedStatusResponse(tt.status)
+			if tt.wantStatus == 0 {
+				if response != nil {
+					t.Errorf("blockedStatusResponse(%q) = %d, want content to be served", tt.status, response.Status)
+				}
+				return
+			}
+			if response == nil || response.Status != tt.wantStatus {
+				t.Errorf("blockedStatusResponse(%q) = %v, want status %d", tt.status, response, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// newSignedLinkApp serves a content route guarded by signedLinkResponse and returns the computed
+// links for a file it can be requested with
+func newSignedLinkApp(t *testing.T, signing bool) (*fiber.App, *models.FileLinks) {
+	t.Helper()
+
+	previous := config.Config
+	t.Cleanup(func() { config.Config = previous })
+	config.Config.Storage.Links = config.LinkConfig{Signing: signing}
+	t.Setenv("URL_SIGNING_KEY", "test-signing-key")
+	t.Setenv("ADMIN_API_KEY", "test-admin-key")
+
+	h := &FileHandler{linkService: services.NewLinkService()}
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error {
+		if response := h.signedLinkResponse(c); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/api/v1/files/:id", handler)
+	app.Get("/api/v1/files/:id/thumbnail", handler)
+
+	file := &models.File{Extension: "jpg"}
+	file.ID = uuid.MustParse("01563e3a-b5d3-d676-4c61-efb99302bd5b")
+	links := h.linkService.BuildLinks(file, services.NewThumbnailService(), services.NewPreviewService())
+	return app, links
+}
+
+func TestSignedLinkResponse(t *testing.T) {
+	app, links := newSignedLinkApp(t, true)
+
+	download, err := url.Parse(links.Download)
+	if err != nil {
+		t.Fatalf("invalid download link %q: %v", links.Download, err)
+	}
+	thumbnail, err := url.Parse(links.Thumbnail)
+	if err != nil {
+		t.Fatalf("invalid thumbnail link %q: %v", links.Thumbnail, err)
+	}
+
+	tampered := download.Query()
+	tampered.Set("expires", "99999999999")
+	otherPath := thumbnail.Query()
+	otherPath.Set("download", "1")
+
+	tests := []struct {
+		name       string
+		target     string
+		admin      bool
+		wantStatus int
+	}{
+		{"signed", links.Download, false, http.StatusOK},
+		{"signed thumbnail", links.Thumbnail, false, http.StatusOK},
+		{"unsigned", download.Path + "?download=1", false, http.StatusForbidden},
+		{"tampered expiry", download.Path + "?" + tampered.Encode(), false, http.StatusForbidden},
+		{"signature for another path", download.Path + "?" + otherPath.Encode(), false, http.StatusForbidden},
+		{"unsigned admin", download.Path + "?download=1", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.admin {
+				req.Header.Set(AdminKeyHeader, "test-admin-key")
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSignedLinkResponseDisabled(t *testing.T) {
+	app, links := newSignedLinkApp(t, false)
+
+	download, err := url.Parse(links.Download)
+	if err != nil {
+		t.Fatalf("invalid download link %q: %v", links.Download, err)
+	}
+	if download.Query().Get("signature") != "" {
+		t.Errorf("download link %q is signed with signing disabled", links.Download)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, download.Path, nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d, want %d", download.Path, resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestAccessWindowResponse(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		from       *time.Time
+		until      *time.Time
+		wantStatus int
+	}{
+		{"no window", nil, nil, 0},
+		{"opened", &past, nil, 0},
+		{"not yet open", &future, nil, http.StatusForbidden},
+		{"open until later", nil, &future, 0},
+		{"closed", nil, &past, http.StatusForbidden},
+		{"inside window", &past, &future, 0},
+		{"before window", &future, &future, http.StatusForbidden},
+		{"after window", &past, &past, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &models.File{AccessibleFrom: tt.from, AccessibleUntil: tt.until}
+			response := accessWindowResponse(file)
+			if tt.wantStatus == 0 {
+				if response != nil {
+					t.Errorf("accessWindowResponse() = %d, want content to be served", response.Status)
+				}
+				return
+			}
+			if response == nil || response.Status != tt.wantStatus {
+				t.Errorf("accessWindowResponse() = %v, want status %d", response, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHotlinkResponse(t *testing.T) {
+	previous := config.Config
+	t.Cleanup(func() { config.Config = previous })
+	config.Config.Storage.Download.HotlinkProtection = config.HotlinkConfig{AllowedOrigins: []string{"example.com"}}
+	t.Setenv("ADMIN_API_KEY", "test-admin-key")
+
+	h := &FileHandler{fileService: services.NewFileService()}
+	app := fiber.New()
+	app.Get("/api/v1/files/:id/raw", func(c *fiber.Ctx) error {
+		if response := h.hotlinkResponse(c); response != nil {
+			return httpx.SendResponse(c, *response)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		origin     string
+		referer    string
+		admin      bool
+		wantStatus int
+	}{
+		{"allowed referer", "", "https://example.com/gallery", false, http.StatusOK},
+		{"allowed origin", "https://example.com", "", false, http.StatusOK},
+		{"other site", "", "https://evil.test/", false, http.StatusForbidden},
+		{"no referer", "", "", false, http.StatusForbidden},
+		{"admin from other site", "", "https://evil.test/", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b/raw", nil)
+			if tt.origin != "" {
+				req.Header.Set(fiber.HeaderOrigin, tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set(fiber.HeaderReferer, tt.referer)
+			}
+			if tt.admin {
+				req.Header.Set(AdminKeyHeader, "test-admin-key")
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET with origin %q, referer %q = %d, want %d", tt.origin, tt.referer, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetFolderArchiveRefused(t *testing.T) {
+	tests := []struct {
+		name       string
+		links      config.LinkConfig
+		hotlink    config.HotlinkConfig
+		target     string
+		referer    string
+		wantStatus int
+	}{
+		{"no prefix", config.LinkConfig{}, config.HotlinkConfig{}, "/api/v1/files/archive.tar.gz", "", http.StatusBadRequest},
+		{"traversal prefix", config.LinkConfig{}, config.HotlinkConfig{}, "/api/v1/files/archive.tar.gz?prefix=../etc", "", http.StatusBadRequest},
+		{"unsigned", config.LinkConfig{Signing: true}, config.HotlinkConfig{}, "/api/v1/files/archive.tar.gz?prefix=docs", "", http.StatusForbidden},
+		{"other site", config.LinkConfig{}, config.HotlinkConfig{AllowedOrigins: []string{"example.com"}}, "/api/v1/files/archive.tar.gz?prefix=docs", "https://evil.test/", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.Config
+			t.Cleanup(func() { config.Config = previous })
+			config.Config.Storage.Links = tt.links
+			config.Config.Storage.Download.HotlinkProtection = tt.hotlink
+			t.Setenv("URL_SIGNING_KEY", "test-signing-key")
+			t.Setenv("ADMIN_API_KEY", "test-admin-key")
+
+			h := &FileHandler{
+				fileService:   services.NewFileService(),
+				folderService: services.NewFolderService(),
+				linkService:   services.NewLinkService(),
+			}
+			app := fiber.New()
+			app.Get("/api/v1/files/archive.tar.gz", h.GetFolderArchive)
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.referer != "" {
+				req.Header.Set(fiber.HeaderReferer, tt.referer)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetThumbnailsRefused(t *testing.T) {
+	tests := []struct {
+		name    string
+		links   config.LinkConfig
+		hotlink config.HotlinkConfig
+		referer string
+	}{
+		{"unsigned", config.LinkConfig{Signing: true}, config.HotlinkConfig{}, ""},
+		{"other site", config.LinkConfig{}, config.HotlinkConfig{AllowedOrigins: []string{"example.com"}}, "https://evil.test/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.Config
+			t.Cleanup(func() { config.Config = previous })
+			config.Config.Storage.Links = tt.links
+			config.Config.Storage.Download.HotlinkProtection = tt.hotlink
+			t.Setenv("URL_SIGNING_KEY", "test-signing-key")
+
+			h := &FileHandler{
+				fileService:      services.NewFileService(),
+				linkService:      services.NewLinkService(),
+				thumbnailService: services.NewThumbnailService(),
+			}
+			app := fiber.New()
+			app.Post("/api/v1/files/thumbnails", h.GetThumbnails)
+
+			body := strings.NewReader(`{"ids":["01563e3a-b5d3-d676-4c61-efb99302bd5b"]}`)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files/thumbnails", body)
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.referer != "" {
+				req.Header.Set(fiber.HeaderReferer, tt.referer)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("POST thumbnails = %d, want %d", resp.StatusCode, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestFormatFingerprintTime(t *testing.T) {
+	if got := formatFingerprintTime(nil); got != "" {
+		t.Errorf("formatFingerprintTime(nil) = %q, want empty", got)
+	}
+
+	at := time.Unix(1700000000, 5)
+	if got, want := formatFingerprintTime(&at), "1700000000000000005"; got != want {
+		t.Errorf("formatFingerprintTime() = %q, want %q", got, want)
+	}
+
+	// Updates within the same second still change the fingerprint
+	later := at.Add(time.Nanosecond)
+	if formatFingerprintTime(&later) == formatFingerprintTime(&at) {
+		t.Error("formatFingerprintTime() ignores sub-second changes")
+	}
+}
+
+func TestStatusController(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"quarantined", "virus scanning"},
+		{"infected", "virus scanning"},
+		{"corrupted", "integrity verification"},
+		{"active", ""},
+		{"deleted", ""},
+	}
+
+	for _, tt := range tests {
+		if got := statusController(tt.status); got != tt.want {
+			t.Errorf("statusController(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"storage-api/internal/requests"
+	"storage-api/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kerimovok/go-pkg-utils/httpx"
+	"github.com/kerimovok/go-pkg-utils/validator"
+)
+
+// FolderHandler handles folder-related HTTP requests
+type FolderHandler struct {
+	folderService *services.FolderService
+}
+
+// NewFolderHandler creates a new folder handler
+func NewFolderHandler() *FolderHandler {
+	return &FolderHandler{
+		folderService: services.NewFolderService(),
+	}
+}
+
+// CreateFolder creates a folder and any missing parent folders
+func (h *FolderHandler) CreateFolder(c *fiber.Ctx) error {
+	var input requests.CreateFolderRequest
+	if err := c.BodyParser(&input); err != nil {
+		response := httpx.BadRequest("Invalid request body", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(&input); err != nil {
+		response := httpx.BadRequest("Validation failed", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	folderPath, err := h.folderService.NormalizePath(input.Path)
+	if err != nil || folderPath == "" {
+		response := httpx.BadRequest("Invalid folder path", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	folder, err := h.folderService.CreateFolder(folderPath)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to create folder", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.Created("Folder created successfully", folder)
+	return httpx.SendResponse(c, response)
+}
+
+// ListFolders lists the direct subfolders of the folder given by the "parent" query parameter
+func (h *FolderHandler) ListFolders(c *fiber.Ctx) error {
+	parentPath, err := h.folderService.NormalizePath(c.Query("parent"))
+	if err != nil {
+		response := httpx.BadRequest("Invalid parent folder", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	folders, err := h.folderService.ListFolders(parentPath)
+	if err != nil {
+		response := httpx.InternalServerError("Failed to fetch folders", err)
+		return httpx.SendResponse(c, response)
+	}
+
+	response := httpx.OK("Folders retrieved successfully", folders)
+	return httpx.SendResponse(c, response)
+}
@@ -1,19 +1,63 @@
 package models
 
 import (
+	"time"
+
 	"github.com/kerimovok/go-pkg-database/sql"
 )
 
 // File represents a stored file
 type File struct {
 	sql.BaseModel
-	OriginalName string `json:"originalName" gorm:"not null"`
-	StoredName   string `json:"storedName" gorm:"not null;uniqueIndex"`
-	FilePath     string `json:"filePath" gorm:"not null"`
-	FileSize     int64  `json:"fileSize" gorm:"not null"`
-	MimeType     string `json:"mimeType" gorm:"not null"`
-	Extension    string `json:"extension" gorm:"not null"`
-	FileType     string `json:"fileType" gorm:"not null"`
-	Hash         string `json:"hash" gorm:"not null;uniqueIndex"`
-	Status       string `json:"status" gorm:"not null;default:'active'"`
+	OriginalName        string           `json:"originalName" gorm:"not null"`
+	RawOriginalName     string           `json:"rawOriginalName,omitempty"`
+	Folder              string           `json:"folder" gorm:"not null;default:'';index"`
+	StoredName          string           `json:"storedName" gorm:"not null;uniqueIndex"`
+	FilePath            string           `json:"filePath" gorm:"not null"`
+	Backend             string           `json:"-" gorm:"not null;default:'local';index"`
+	FileSize            int64            `json:"fileSize" gorm:"not null"`
+	MimeType            string           `json:"mimeType" gorm:"not null"`
+	DeclaredMimeType    string           `json:"declaredMimeType,omitempty"`
+	ContentTypeOverride string           `json:"contentTypeOverride,omitempty"`
+	Extension           string           `json:"extension" gorm:"not null"`
+	FileType            string           `json:"fileType" gorm:"not null"`
+	Hash                string           `json:"hash" gorm:"not null;uniqueIndex"`
+	HashAlgorithm       string           `json:"hashAlgorithm" gorm:"not null;default:'md5';index"`
+	Status              string           `json:"status" gorm:"not null;default:'active'"`
+	PerceptualHash      string           `json:"perceptualHash,omitempty" gorm:"index"`
+	OriginalFilePath    string           `json:"-"`
+	HasGzipVariant      bool             `json:"hasGzipVariant" gorm:"not null;default:false"`
+	AccessibleFrom      *time.Time       `json:"accessibleFrom,omitempty"`
+	AccessibleUntil     *time.Time       `json:"accessibleUntil,omitempty"`
+	CreatedByRequestID  string           `json:"-" gorm:"index"`
+	UploaderIP          string           `json:"-" gorm:"index"`
+	UploaderUserAgent   string           `json:"-"`
+	StorageLocation     *StorageLocation `json:"storageLocation,omitempty" gorm:"-"`
+	Audit               *FileAudit       `json:"audit,omitempty" gorm:"-"`
+	Deduplicated        bool             `json:"deduplicated,omitempty" gorm:"-"`
+	Tags                []string         `json:"tags,omitempty" gorm:"-"`
+	Links               *FileLinks       `json:"links,omitempty" gorm:"-"`
+	ContentBase64       string           `json:"contentBase64,omitempty" gorm:"-"`
+}
+
+// StorageLocation describes where a file's content is stored; it is only reported to admins
+type StorageLocation struct {
+	Backend string `json:"backend"`
+	Type    string `json:"type"`
+	BaseDir string `json:"baseDir"`
+}
+
+// FileAudit holds provenance details of a file; it is only reported to admins
+type FileAudit struct {
+	CreatedByRequestID string `json:"createdByRequestId,omitempty"`
+	UploaderIP         string `json:"uploaderIp,omitempty"`
+	UploaderUserAgent  string `json:"uploaderUserAgent,omitempty"`
+}
+
+// FileLinks holds computed URLs for a file's content; they are only included when requested
+type FileLinks struct {
+	Download  string `json:"download"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Preview   string `json:"preview,omitempty"`
+	Original  string `json:"original,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// FileContent holds plain text extracted from a document for full-text search
+type FileContent struct {
+	sql.BaseModel
+	FileID  uuid.UUID `json:"fileId" gorm:"type:uuid;not null;uniqueIndex"`
+	Content string    `json:"content" gorm:"type:text;not null"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// FileDigest caches a digest of a file's content computed on request. SourceHash is the file's
+// stored hash at the time, so the digest no longer applies once the content changes.
+type FileDigest struct {
+	sql.BaseModel
+	FileID     uuid.UUID `json:"fileId" gorm:"type:uuid;not null;uniqueIndex:idx_file_digests_file_algorithm"`
+	Algorithm  string    `json:"algorithm" gorm:"not null;uniqueIndex:idx_file_digests_file_algorithm"`
+	Digest     string    `json:"digest" gorm:"not null"`
+	SourceHash string    `json:"-" gorm:"not null"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// FilePathHistory records a physical location a file's content has been stored at
+type FilePathHistory struct {
+	sql.BaseModel
+	FileID   uuid.UUID `json:"fileId" gorm:"type:uuid;not null;index"`
+	Backend  string    `json:"backend" gorm:"not null"`
+	FilePath string    `json:"filePath" gorm:"not null"`
+	Reason   string    `json:"reason" gorm:"not null"`
+}
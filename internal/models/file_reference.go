@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// FileReference records that an external system uses a file. Consumer names the system and
+// Reference identifies what refers to the file there, so each use is counted once.
+type FileReference struct {
+	sql.BaseModel
+	FileID    uuid.UUID `json:"fileId" gorm:"type:uuid;not null;uniqueIndex:idx_file_references_file_consumer_reference"`
+	Consumer  string    `json:"consumer" gorm:"not null;uniqueIndex:idx_file_references_file_consumer_reference"`
+	Reference string    `json:"reference" gorm:"not null;default:'';uniqueIndex:idx_file_references_file_consumer_reference"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// FileTag associates a tag with a file
+type FileTag struct {
+	sql.BaseModel
+	FileID uuid.UUID `json:"fileId" gorm:"type:uuid;not null;uniqueIndex:idx_file_tags_file_tag"`
+	Tag    string    `json:"tag" gorm:"not null;uniqueIndex:idx_file_tags_file_tag;index"`
+}
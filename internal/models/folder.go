@@ -0,0 +1,14 @@
+package models
+
+import (
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// Folder represents a virtual folder that files can be uploaded into.
+// Path is the normalized slash-separated path (e.g. "reports/2024"); the root folder has no record.
+type Folder struct {
+	sql.BaseModel
+	Path       string `json:"path" gorm:"not null;uniqueIndex"`
+	Name       string `json:"name" gorm:"not null"`
+	ParentPath string `json:"parentPath" gorm:"not null;default:'';index"`
+}
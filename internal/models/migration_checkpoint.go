@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// MigrationCheckpoint records the progress of a resumable background migration
+type MigrationCheckpoint struct {
+	sql.BaseModel
+	Name        string     `json:"name" gorm:"not null;uniqueIndex"`
+	LastID      string     `json:"lastId"`
+	Processed   int64      `json:"processed" gorm:"not null;default:0"`
+	Failed      int64      `json:"failed" gorm:"not null;default:0"`
+	Completed   bool       `json:"completed" gorm:"not null;default:false"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
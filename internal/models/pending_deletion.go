@@ -0,0 +1,14 @@
+package models
+
+import (
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// PendingDeletion records stored content that couldn't be removed and is retried by garbage collection
+type PendingDeletion struct {
+	sql.BaseModel
+	Backend   string `json:"backend" gorm:"not null"`
+	Path      string `json:"path" gorm:"not null;uniqueIndex"`
+	Attempts  int    `json:"attempts" gorm:"not null;default:0"`
+	LastError string `json:"lastError"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/kerimovok/go-pkg-database/sql"
+)
+
+// RejectedUpload records a file that was blocked by upload validation
+type RejectedUpload struct {
+	sql.BaseModel
+	FileName    string `json:"fileName" gorm:"not null"`
+	FileSize    int64  `json:"fileSize" gorm:"not null"`
+	ContentType string `json:"contentType"`
+	ReasonCode  string `json:"reasonCode" gorm:"not null;index"`
+	Reason      string `json:"reason"`
+}
@@ -0,0 +1,16 @@
+package requests
+
+// SetMaintenanceRequest pauses or resumes uploads for maintenance
+type SetMaintenanceRequest struct {
+	Enabled           *bool  `json:"enabled" validate:"required"`
+	Message           string `json:"message,omitempty" validate:"max=500"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds,omitempty" validate:"min=0"`
+}
+
+// RegenerateThumbnailsRequest selects the image files whose thumbnails are regenerated; empty
+// filters select all of them
+type RegenerateThumbnailsRequest struct {
+	Folder     string   `json:"folder,omitempty"`
+	Extensions []string `json:"extensions,omitempty" validate:"max=20"`
+	DryRun     bool     `json:"dryRun,omitempty"`
+}
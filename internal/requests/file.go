@@ -4,20 +4,88 @@ import (
 	"time"
 )
 
-// UpdateFileRequest represents a file update request
+// ReplaceFileRequest represents a full metadata replacement (PUT); omitted optional fields are reset,
+// except the status, which is kept
+type ReplaceFileRequest struct {
+	FileName            string     `json:"fileName" validate:"required"`
+	Status              *string    `json:"status,omitempty" validate:"omitempty,oneof=active inactive archived deleted"`
+	ContentTypeOverride *string    `json:"contentTypeOverride,omitempty"`
+	AccessibleFrom      *time.Time `json:"accessibleFrom,omitempty"`
+	AccessibleUntil     *time.Time `json:"accessibleUntil,omitempty"`
+}
+
+// UpdateFileRequest represents a partial metadata update (PATCH); omitted fields are left unchanged
 type UpdateFileRequest struct {
-	FileName *string `json:"fileName,omitempty"`
-	Status   *string `json:"status,omitempty" validate:"omitempty,oneof=active inactive archived deleted"`
+	FileName            *string    `json:"fileName,omitempty"`
+	Status              *string    `json:"status,omitempty" validate:"omitempty,oneof=active inactive archived deleted"`
+	ContentTypeOverride *string    `json:"contentTypeOverride,omitempty"`
+	AccessibleFrom      *time.Time `json:"accessibleFrom,omitempty"`
+	AccessibleUntil     *time.Time `json:"accessibleUntil,omitempty"`
 }
 
 // FileSearchRequest represents a file search request
 type FileSearchRequest struct {
 	FileType       string     `json:"fileType,omitempty"`
-	Status         string     `json:"status,omitempty" validate:"omitempty,oneof=active inactive archived deleted"`
+	Name           string     `json:"name,omitempty"`
+	Content        string     `json:"content,omitempty"`
+	HashPrefix     string     `json:"hashPrefix,omitempty"`
+	Tag            string     `json:"tag,omitempty"`
+	UploaderIP     string     `json:"uploaderIp,omitempty"`
+	Folder         *string    `json:"folder,omitempty"`
+	Status         string     `json:"status,omitempty" validate:"omitempty,oneof=active inactive archived deleted quarantined infected corrupted"`
 	UploadedAfter  *time.Time `json:"uploadedAfter,omitempty"`
 	UploadedBefore *time.Time `json:"uploadedBefore,omitempty"`
-	Page           int        `json:"page" validate:"min=1"`
-	Limit          int        `json:"limit" validate:"min=1,max=100"`
+	Page           int        `json:"page" validate:"omitempty,min=1"`
+	Limit          int        `json:"limit" validate:"omitempty,min=1"`
 	SortBy         string     `json:"sortBy" validate:"omitempty,oneof=created_at updated_at original_name file_size"`
 	SortOrder      string     `json:"sortOrder" validate:"omitempty,oneof=asc desc"`
+	Fields         string     `json:"fields,omitempty"`
+}
+
+// FileExportRequest represents a streamed export of the files matching search filters. Cursor
+// resumes an interrupted export after the record with that ID.
+type FileExportRequest struct {
+	FileSearchRequest
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// FileTimelineRequest represents an upload activity histogram request
+type FileTimelineRequest struct {
+	Interval       string     `json:"interval" validate:"omitempty,oneof=day week month"`
+	FileType       string     `json:"fileType,omitempty"`
+	UploadedAfter  *time.Time `json:"uploadedAfter,omitempty"`
+	UploadedBefore *time.Time `json:"uploadedBefore,omitempty"`
+}
+
+// ThumbnailBatchRequest represents a request for thumbnails of multiple files
+type ThumbnailBatchRequest struct {
+	IDs []string `json:"ids" validate:"required"`
+}
+
+// BulkTagRequest represents a request to add and remove tags across multiple files
+type BulkTagRequest struct {
+	IDs    []string `json:"ids" validate:"required"`
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// FileReferenceRequest identifies an external reference to a file: the consuming system and,
+// optionally, what refers to the file there
+type FileReferenceRequest struct {
+	Consumer  string `json:"consumer" validate:"required,max=100"`
+	Reference string `json:"reference,omitempty" validate:"max=255"`
+}
+
+// CreateFolderRequest represents a folder creation request
+type CreateFolderRequest struct {
+	Path string `json:"path" validate:"required"`
+}
+
+// UploadFromURLRequest represents an upload-from-URL request
+type UploadFromURLRequest struct {
+	URL      string   `json:"url" validate:"required"`
+	FileName string   `json:"fileName,omitempty"`
+	Folder   string   `json:"folder,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Status   string   `json:"status,omitempty"`
 }
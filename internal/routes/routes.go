@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"storage-api/internal/database"
 	"storage-api/internal/handlers"
 	"time"
 
@@ -18,8 +19,12 @@ func SetupRoutes(app *fiber.App) {
 
 	// Health check route
 	app.Get("/health", func(c *fiber.Ctx) error {
+		status := "healthy"
+		if !database.IsConnected() {
+			status = "degraded"
+		}
 		return c.JSON(fiber.Map{
-			"status":    "healthy",
+			"status":    status,
 			"service":   "storage-api",
 			"timestamp": time.Now().UTC(),
 		})
@@ -30,9 +35,56 @@ func SetupRoutes(app *fiber.App) {
 
 	files := v1.Group("/files")
 	files.Post("/", fileHandler.UploadFile)
+	files.Put("/", fileHandler.UploadRawFile)
+	files.Post("/from-url", fileHandler.UploadFromURL)
 	files.Get("/", fileHandler.SearchFiles)
+	files.Get("/export", fileHandler.ExportFiles)
 	files.Get("/limits", fileHandler.GetFileLimits)
+	files.Get("/check", fileHandler.CheckFile)
+	files.Get("/recent", fileHandler.GetRecentFiles)
+	files.Get("/timeline", fileHandler.GetFileTimeline)
+	files.Post("/thumbnails", fileHandler.GetThumbnails)
+	files.Post("/bulk-tag", fileHandler.BulkTagFiles)
+	files.Get("/archive.tar.gz", fileHandler.GetFolderArchive)
+	files.Get("/by-name/:storedName", fileHandler.GetFileByStoredName)
 	files.Get("/:id", fileHandler.GetFile)
-	files.Put("/:id", fileHandler.UpdateFile)
+	files.Get("/:id/original", fileHandler.GetOriginalFile)
+	files.Get("/:id/path-history", fileHandler.GetFilePathHistory)
+	files.Get("/:id/raw", fileHandler.GetRawFile)
+	files.Get("/:id/preview", fileHandler.GetFilePreview)
+	files.Get("/:id/thumbnail", fileHandler.GetFileThumbnail)
+	files.Get("/:id/similar", fileHandler.GetSimilarFiles)
+	files.Get("/:id/external-references", fileHandler.ListExternalReferences)
+	files.Post("/:id/external-references", fileHandler.AddExternalReference)
+	files.Delete("/:id/external-references", fileHandler.RemoveExternalReference)
+	files.Get("/:id/checksum", fileHandler.GetFileChecksum)
+	files.Get("/:id/verify", fileHandler.VerifyFileHash)
+	files.Put("/:id", fileHandler.ReplaceFile)
+	files.Patch("/:id", fileHandler.UpdateFile)
 	files.Delete("/:id", fileHandler.DeleteFile)
+
+	// Folder routes
+	folderHandler := handlers.NewFolderHandler()
+
+	folders := v1.Group("/folders")
+	folders.Post("/", folderHandler.CreateFolder)
+	folders.Get("/", folderHandler.ListFolders)
+
+	// Admin routes require the admin API key
+	adminHandler := handlers.NewAdminHandler()
+
+	admin := v1.Group("/admin", handlers.RequireAdmin)
+	admin.Post("/rehash", adminHandler.StartRehash)
+	admin.Get("/rehash", adminHandler.GetRehashProgress)
+	admin.Post("/stored-names/check", adminHandler.CheckStoredNames)
+	admin.Get("/rejected-uploads", adminHandler.ListRejectedUploads)
+	admin.Post("/integrity", adminHandler.StartIntegrityCheck)
+	admin.Get("/integrity", adminHandler.GetIntegrityStatus)
+	admin.Post("/reindex", adminHandler.StartReindex)
+	admin.Get("/reindex", adminHandler.GetReindexStatus)
+	admin.Post("/thumbnails/regenerate", adminHandler.RegenerateThumbnails)
+	admin.Get("/thumbnails/regenerate", adminHandler.GetThumbnailRegenerationStatus)
+	admin.Get("/config", adminHandler.GetEffectiveConfig)
+	admin.Get("/maintenance", adminHandler.GetMaintenance)
+	admin.Put("/maintenance", adminHandler.SetMaintenance)
 }
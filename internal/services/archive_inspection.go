@@ -0,0 +1,81 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+
+	"storage-api/internal/utils"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// zipSignature is the local file header magic that starts every ZIP archive (including docx, jar, ...)
+var zipSignature = []byte("PK\x03\x04")
+
+// validateArchive rejects ZIP archives whose declared contents exceed the configured limits.
+// Only the central directory is read; nothing is extracted.
+func (s *FileService) validateArchive(file *UploadSource) error {
+	limits := s.config.Validation.ArchiveLimits
+	if !limits.Enabled {
+		return nil
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for archive inspection")
+	}
+	defer src.Close()
+
+	header := make([]byte, len(zipSignature))
+	if _, err := io.ReadFull(src, header); err != nil || !bytes.Equal(header, zipSignature) {
+		// Not a ZIP archive
+		return nil
+	}
+
+	// zip.NewReader needs random access; fall back to buffering sources that don't provide it
+	readerAt, ok := src.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return errors.InternalError("FILE_READ_ERROR", "Failed to read file for archive inspection")
+		}
+		readerAt = bytes.NewReader(append(header, data...))
+	}
+
+	archive, err := zip.NewReader(readerAt, file.Size)
+	if err != nil {
+		return errors.BadRequestError("INVALID_ARCHIVE", fmt.Sprintf("Failed to read archive: %v", err))
+	}
+
+	if limits.MaxEntries > 0 && len(archive.File) > limits.MaxEntries {
+		return errors.BadRequestError("ARCHIVE_BOMB", fmt.Sprintf("Archive contains %d entries, exceeding the limit of %d", len(archive.File), limits.MaxEntries))
+	}
+
+	var maxUncompressed uint64
+	if limits.MaxUncompressedSize != "" {
+		size, err := utils.ParseSizeString(limits.MaxUncompressedSize)
+		if err != nil {
+			return errors.InternalError("INVALID_CONFIG", fmt.Sprintf("Invalid archive_limits.max_uncompressed_size: %v", err))
+		}
+		maxUncompressed = uint64(size)
+	}
+
+	var totalUncompressed, totalCompressed uint64
+	for _, entry := range archive.File {
+		totalUncompressed += entry.UncompressedSize64
+		totalCompressed += entry.CompressedSize64
+
+		// Check while summing so crafted sizes can't overflow the total
+		if maxUncompressed > 0 && totalUncompressed > maxUncompressed {
+			return errors.BadRequestError("ARCHIVE_BOMB", fmt.Sprintf("Archive uncompressed size exceeds the limit of %s", limits.MaxUncompressedSize))
+		}
+	}
+
+	if limits.MaxCompressionRatio > 0 && totalCompressed > 0 && totalUncompressed/totalCompressed > uint64(limits.MaxCompressionRatio) {
+		return errors.BadRequestError("ARCHIVE_BOMB", fmt.Sprintf("Archive compression ratio exceeds the limit of %d:1", limits.MaxCompressionRatio))
+	}
+
+	return nil
+}
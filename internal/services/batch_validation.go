@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// FileRejection describes why a single file in an upload batch was rejected
+type FileRejection struct {
+	FileName string `json:"file_name"`
+	Code     string `json:"code"`
+	Reason   string `json:"reason"`
+	Rule     string `json:"rule,omitempty"`
+}
+
+// PredominantRejection summarizes a rejection shared by every failed file in a batch
+type PredominantRejection struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+	Rule   string `json:"rule,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// BatchValidationError reports every file rejected from an upload batch. When all rejections share
+// the same code and rule, it is surfaced as the predominant error so clients know what to adjust.
+type BatchValidationError struct {
+	Rejections       []FileRejection       `json:"rejections"`
+	PredominantError *PredominantRejection `json:"predominant_error,omitempty"`
+}
+
+// Error returns the predominant reason, or a count of the rejected files when reasons differ
+func (e *BatchValidationError) Error() string {
+	if e.PredominantError != nil {
+		return e.PredominantError.Reason
+	}
+	return fmt.Sprintf("%d files failed validation", len(e.Rejections))
+}
+
+// newBatchValidationError builds the batch error and determines its predominant rejection
+func newBatchValidationError(rejections []FileRejection) *BatchValidationError {
+	batchErr := &BatchValidationError{Rejections: rejections}
+
+	first := rejections[0]
+	for _, rejection := range rejections[1:] {
+		if rejection.Code != first.Code || rejection.Rule != first.Rule {
+			return batchErr
+		}
+	}
+
+	batchErr.PredominantError = &PredominantRejection{
+		Code:   first.Code,
+		Reason: first.Reason,
+		Rule:   first.Rule,
+		Count:  len(rejections),
+	}
+	return batchErr
+}
+
+// newFileRejection describes a validation error for a file
+func newFileRejection(file *UploadSource, rule string, err error) FileRejection {
+	rejection := FileRejection{
+		FileName: file.Filename,
+		Code:     "VALIDATION_FAILED",
+		Reason:   err.Error(),
+		Rule:     rule,
+	}
+	if appErr, ok := err.(*errors.Error); ok {
+		rejection.Code = appErr.Code
+		rejection.Reason = appErr.Message
+	}
+	return rejection
+}
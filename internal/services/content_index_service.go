@@ -0,0 +1,273 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContentIndexService extracts plain text from documents and indexes it for full-text search
+type ContentIndexService struct {
+	config          config.ContentIndexConfig
+	fallbackCharset string
+	fileService     *FileService
+}
+
+// NewContentIndexService creates a new content index service instance
+func NewContentIndexService() *ContentIndexService {
+	storageConfig := config.GetConfig().Storage
+	return &ContentIndexService{
+		config:          storageConfig.ContentIndex,
+		fallbackCharset: storageConfig.Download.FallbackCharset,
+		fileService:     NewFileService(),
+	}
+}
+
+// IsEnabled reports whether content extraction and search are enabled
+func (s *ContentIndexService) IsEnabled() bool {
+	return s.config.Enabled
+}
+
+// GetLanguage returns the PostgreSQL text search configuration used for indexing and queries
+func (s *ContentIndexService) GetLanguage() string {
+	if s.config.Language == "" {
+		return "simple"
+	}
+	return s.config.Language
+}
+
+// getMaxTextSize returns the maximum number of extracted bytes stored per file
+func (s *ContentIndexService) getMaxTextSize() int64 {
+	if s.config.MaxTextSize <= 0 {
+		return 1024 * 1024
+	}
+	return s.config.MaxTextSize
+}
+
+// SupportsFile reports whether text can be extracted from the file
+func (s *ContentIndexService) SupportsFile(file *models.File) bool {
+	switch strings.ToLower(file.Extension) {
+	case "pdf", "docx":
+		return true
+	}
+	return IsTextFile(file)
+}
+
+// IndexAsync extracts and stores a file's text in the background
+func (s *ContentIndexService) IndexAsync(file models.File) {
+	go func() {
+		if err := s.IndexFile(&file); err != nil {
+			log.Printf("Warning: Failed to index content of %s: %v", file.OriginalName, err)
+		}
+	}()
+}
+
+// IndexFile extracts a file's text and stores it, replacing any previously indexed text
+func (s *ContentIndexService) IndexFile(file *models.File) error {
+	text, err := s.Extract(file)
+	if err != nil {
+		return err
+	}
+
+	content := models.FileContent{FileID: file.ID, Content: text}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"content": text, "updated_at": time.Now()}),
+	}).Create(&content).Error
+}
+
+// DeleteContent removes the indexed text of a file
+func (s *ContentIndexService) DeleteContent(file *models.File) error {
+	return database.DB.Where("file_id = ?", file.ID).Delete(&models.FileContent{}).Error
+}
+
+// FilterByContent restricts a file query to files whose indexed text matches the search terms
+func (s *ContentIndexService) FilterByContent(query *gorm.DB, terms string) *gorm.DB {
+	language := s.GetLanguage()
+	return query.Where(
+		"id IN (SELECT file_id FROM file_contents WHERE deleted_at IS NULL AND to_tsvector(?::regconfig, content) @@ plainto_tsquery(?::regconfig, ?))",
+		language, language, terms)
+}
+
+// OrderByContentRank orders a file query by how well each file's indexed text matches the search terms
+func (s *ContentIndexService) OrderByContentRank(query *gorm.DB, terms string) *gorm.DB {
+	language := s.GetLanguage()
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  "(SELECT ts_rank(to_tsvector(?::regconfig, content), plainto_tsquery(?::regconfig, ?)) FROM file_contents WHERE file_contents.file_id = files.id AND file_contents.deleted_at IS NULL) DESC",
+		Vars: []interface{}{language, language, terms},
+	}})
+}
+
+// Extract returns the plain text of a document, truncated to the configured maximum size
+func (s *ContentIndexService) Extract(file *models.File) (string, error) {
+	if !s.SupportsFile(file) {
+		return "", errors.BadRequestError("CONTENT_EXTRACTION_UNSUPPORTED", "Text extraction is not supported for this file type")
+	}
+
+	text, err := s.extractText(file)
+	if err != nil {
+		return "", errors.InternalError("CONTENT_EXTRACTION_ERROR", fmt.Sprintf("Failed to extract text: %v", err))
+	}
+
+	// PostgreSQL text columns can't hold NUL bytes
+	text = strings.ReplaceAll(text, "\x00", "")
+	return truncateText(text, s.getMaxTextSize()), nil
+}
+
+// extractText reads a file's content through its backend and extracts its text. PDF and DOCX
+// extraction need a local file, so their content is copied to a temporary file first.
+func (s *ContentIndexService) extractText(file *models.File) (string, error) {
+	extension := strings.ToLower(file.Extension)
+	if extension != "pdf" && extension != "docx" {
+		src, err := s.fileService.GetBackend(file.Backend).Open(file.FilePath)
+		if err != nil {
+			return "", err
+		}
+		defer src.Close()
+		return s.extractPlainText(src)
+	}
+
+	path, err := s.fileService.CopyToTemp(file)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	if extension == "pdf" {
+		return s.extractPDF(path)
+	}
+	return extractDOCX(path, s.getMaxTextSize())
+}
+
+// extractPDF runs the configured extractor, which writes the PDF's text to stdout
+func (s *ContentIndexService) extractPDF(path string) (string, error) {
+	extractor := s.config.PDFExtractor
+	if extractor == "" {
+		extractor = "pdftotext"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, extractor, "-enc", "UTF-8", path, "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// extractPlainText reads the start of a text file, decoding non-UTF-8 content with the
+// configured fallback charset
+func (s *ContentIndexService) extractPlainText(src io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(src, s.getMaxTextSize()))
+	if err != nil {
+		return "", err
+	}
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+
+	// Reading stops at the size limit, so trim a rune cut in half before checking for UTF-8
+	trimmed := data
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return string(trimmed), nil
+	}
+
+	fallback := s.fallbackCharset
+	if fallback == "" {
+		fallback = "windows-1252"
+	}
+	if enc, _, err := lookupCharset(fallback); err == nil {
+		if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// extractDOCX collects the text runs of a Word document's main body, one paragraph per line
+func extractDOCX(path string, maxSize int64) (string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		if entry.Name != "word/document.xml" {
+			continue
+		}
+
+		r, err := entry.Open()
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+
+		var text strings.Builder
+		decoder := xml.NewDecoder(r)
+		inText := false
+		for int64(text.Len()) < maxSize {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				inText = t.Name.Local == "t"
+				if t.Name.Local == "tab" {
+					text.WriteByte('\t')
+				}
+			case xml.EndElement:
+				inText = false
+				if t.Name.Local == "p" {
+					text.WriteByte('\n')
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+		return text.String(), nil
+	}
+
+	return "", fmt.Errorf("word/document.xml not found")
+}
+
+// truncateText shortens text to at most maxSize bytes without splitting a UTF-8 sequence
+func truncateText(text string, maxSize int64) string {
+	if int64(len(text)) <= maxSize {
+		return text
+	}
+	cut := int(maxSize)
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
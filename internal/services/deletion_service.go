@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// DeletionService removes stored content, retrying failed removals and queueing paths that
+// still can't be removed so garbage collection eventually cleans them up
+type DeletionService struct {
+	config   config.DeletionConfig
+	backends map[string]StorageBackend
+}
+
+// NewDeletionService creates a new deletion service instance
+func NewDeletionService() *DeletionService {
+	storageConfig := config.GetConfig().Storage
+	return &DeletionService{
+		config:   storageConfig.Deletion,
+		backends: newStorageBackends(storageConfig.Storage),
+	}
+}
+
+// getBackend returns the named backend, falling back to the default one
+func (s *DeletionService) getBackend(name string) StorageBackend {
+	if backend, ok := s.backends[name]; ok {
+		return backend
+	}
+	return s.backends[DefaultBackendName]
+}
+
+// Delete removes a path from a backend with bounded retries. A path that's already gone counts
+// as removed; one that still fails is queued for garbage collection and the error returned.
+// Permission and read-only errors won't clear up by retrying, so they're returned without queueing.
+func (s *DeletionService) Delete(backendName, path string) error {
+	backend := s.getBackend(backendName)
+	err := s.removeWithRetry(backend, path)
+	if err == nil {
+		return nil
+	}
+	if isPermanentRemovalError(err) {
+		return err
+	}
+
+	if queueErr := s.enqueue(backend.Name(), path, err); queueErr != nil {
+		return fmt.Errorf("%w (failed to queue for cleanup: %v)", err, queueErr)
+	}
+	return fmt.Errorf("%w (queued for cleanup)", err)
+}
+
+// removeWithRetry removes a path, doubling the delay after each failed attempt. Permanent
+// errors end the attempts immediately.
+func (s *DeletionService) removeWithRetry(backend StorageBackend, path string) error {
+	delay := time.Duration(s.config.RetryDelayMs) * time.Millisecond
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+
+	var err error
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if err = backend.Delete(path); err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		if isPermanentRemovalError(err) {
+			return err
+		}
+
+		if attempt < s.config.MaxRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// isPermanentRemovalError reports whether a removal failed for a reason retrying can't fix
+func isPermanentRemovalError(err error) bool {
+	return os.IsPermission(err) || errors.Is(err, syscall.EROFS)
+}
+
+// enqueue records a path for garbage collection, bumping the attempt count if it's already queued
+func (s *DeletionService) enqueue(backendName, path string, cause error) error {
+	pending := models.PendingDeletion{
+		Backend:   backendName,
+		Path:      path,
+		Attempts:  1,
+		LastError: cause.Error(),
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "path"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"attempts":   clause.Expr{SQL: "pending_deletions.attempts + 1"},
+			"last_error": pending.LastError,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&pending).Error
+}
+
+// CollectPending retries every queued deletion once, dropping entries whose path is gone
+func (s *DeletionService) CollectPending() {
+	var pending []models.PendingDeletion
+	if err := database.DB.Order("created_at").Find(&pending).Error; err != nil {
+		log.Printf("Warning: Failed to load pending deletions: %v", err)
+		return
+	}
+
+	removed := 0
+	for _, entry := range pending {
+		err := s.getBackend(entry.Backend).Delete(entry.Path)
+		if err != nil && !os.IsNotExist(err) {
+			// Entries that can never be removed are dropped instead of retried forever
+			if isPermanentRemovalError(err) {
+				log.Printf("Warning: Dropping pending deletion %s, which can't be removed: %v", entry.Path, err)
+				database.DB.Delete(&entry)
+				continue
+			}
+			database.DB.Model(&entry).Updates(map[string]interface{}{
+				"attempts":   entry.Attempts + 1,
+				"last_error": err.Error(),
+			})
+			continue
+		}
+
+		if err := database.DB.Delete(&entry).Error; err != nil {
+			log.Printf("Warning: Failed to remove pending deletion %s: %v", entry.Path, err)
+			continue
+		}
+		removed++
+	}
+
+	if len(pending) > 0 {
+		log.Printf("Deletion GC removed %d of %d queued paths", removed, len(pending))
+	}
+}
+
+// StartSchedule runs garbage collection of queued deletions periodically when an interval is configured
+func (s *DeletionService) StartSchedule() {
+	if s.config.GCIntervalMinutes <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.config.GCIntervalMinutes) * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.CollectPending()
+		}
+	}()
+}
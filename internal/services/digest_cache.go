@@ -0,0 +1,48 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetFileDigest returns the digest of a file's content for the algorithm and whether it was served
+// from the digest cache. Digests are read from disk once per algorithm and content version; a cached
+// digest is discarded when the file's stored hash no longer matches the one it was computed for.
+func (s *FileService) GetFileDigest(file *models.File, algorithm string) (string, bool, error) {
+	var cached models.FileDigest
+	err := database.DB.Where("file_id = ? AND algorithm = ?", file.ID, algorithm).First(&cached).Error
+	if err == nil && cached.SourceHash == file.Hash {
+		return cached.Digest, true, nil
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.Printf("Warning: Failed to read cached %s digest of file %s: %v", algorithm, file.ID, err)
+	}
+
+	digest, err := s.CalculateFileHashWithAlgorithm(file.Backend, file.FilePath, algorithm)
+	if err != nil {
+		return "", false, err
+	}
+
+	entry := models.FileDigest{FileID: file.ID, Algorithm: algorithm, Digest: digest, SourceHash: file.Hash}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}, {Name: "algorithm"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"digest": digest, "source_hash": file.Hash, "updated_at": time.Now()}),
+	}).Create(&entry).Error; err != nil {
+		log.Printf("Warning: Failed to cache %s digest of file %s: %v", algorithm, file.ID, err)
+	}
+
+	return digest, false, nil
+}
+
+// DeleteFileDigests removes the cached digests of a file. Rows are removed permanently so the unique
+// index doesn't keep a digest from being cached again.
+func (s *FileService) DeleteFileDigests(fileID uuid.UUID) error {
+	return database.DB.Unscoped().Where("file_id = ?", fileID).Delete(&models.FileDigest{}).Error
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"log"
+	"syscall"
+
+	"storage-api/internal/config"
+	"storage-api/internal/utils"
+)
+
+// Low-disk thresholds reported when an upload is rejected
+const (
+	// LowDiskSoft rejects only uploads larger than the soft maximum upload size
+	LowDiskSoft = "soft"
+	// LowDiskHard rejects all uploads
+	LowDiskHard = "hard"
+)
+
+// DiskSpaceGuard rejects uploads when free space in the upload directory runs low. Below the soft
+// threshold only large uploads are refused, so small files keep working while space is reclaimed;
+// below the hard threshold every upload is refused.
+type DiskSpaceGuard struct {
+	dir               string
+	softThreshold     int64
+	hardThreshold     int64
+	softMaxUploadSize int64
+}
+
+// NewDiskSpaceGuard creates a new disk space guard from the upload configuration
+func NewDiskSpaceGuard() *DiskSpaceGuard {
+	storageConfig := config.GetConfig().Storage
+	lowDisk := storageConfig.Upload.LowDisk
+
+	return &DiskSpaceGuard{
+		dir:               storageConfig.Storage.UploadDir,
+		softThreshold:     parseOptionalSize(lowDisk.SoftThreshold),
+		hardThreshold:     parseOptionalSize(lowDisk.HardThreshold),
+		softMaxUploadSize: parseOptionalSize(lowDisk.SoftMaxUploadSize),
+	}
+}
+
+// IsEnabled reports whether any low-disk threshold is configured
+func (g *DiskSpaceGuard) IsEnabled() bool {
+	return g.softThreshold > 0 || g.hardThreshold > 0
+}
+
+// Check reports which threshold, if any, rejects an upload of the given size. It returns "" when
+// the upload may proceed. Failing to read the free space never blocks uploads.
+func (g *DiskSpaceGuard) Check(size int64) string {
+	if !g.IsEnabled() {
+		return ""
+	}
+
+	free, err := getFreeSpace(g.dir)
+	if err != nil {
+		log.Printf("Warning: Failed to read free disk space for %s: %v", g.dir, err)
+		return ""
+	}
+
+	if g.hardThreshold > 0 && free < g.hardThreshold {
+		return LowDiskHard
+	}
+	if g.softThreshold > 0 && free < g.softThreshold && size > g.softMaxUploadSize {
+		return LowDiskSoft
+	}
+	return ""
+}
+
+// GetSoftMaxUploadSize returns the largest upload accepted below the soft threshold
+func (g *DiskSpaceGuard) GetSoftMaxUploadSize() int64 {
+	return g.softMaxUploadSize
+}
+
+// getFreeSpace returns the bytes available to unprivileged users on the filesystem holding dir
+func getFreeSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// parseOptionalSize parses a size setting, treating empty or invalid values as unset
+func parseOptionalSize(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	size, err := utils.ParseSizeString(value)
+	if err != nil {
+		log.Printf("Warning: Invalid size '%s', ignoring", value)
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"sync"
+
+	"storage-api/internal/config"
+)
+
+// DownloadLimiter caps the number of concurrent download streams per file and overall
+type DownloadLimiter struct {
+	mu         sync.Mutex
+	active     map[string]int
+	total      int
+	maxPerFile int
+	maxTotal   int
+	retryAfter int
+}
+
+// NewDownloadLimiter creates a new download limiter from the download configuration
+func NewDownloadLimiter() *DownloadLimiter {
+	downloadConfig := config.GetConfig().Storage.Download
+	return &DownloadLimiter{
+		active:     make(map[string]int),
+		maxPerFile: downloadConfig.MaxConcurrentPerFile,
+		maxTotal:   downloadConfig.MaxConcurrentTotal,
+		retryAfter: downloadConfig.RetryAfterSeconds,
+	}
+}
+
+// IsEnabled reports whether any concurrency limit is configured
+func (l *DownloadLimiter) IsEnabled() bool {
+	return l.maxPerFile > 0 || l.maxTotal > 0
+}
+
+// GetRetryAfter returns the number of seconds clients should wait before retrying a rejected download
+func (l *DownloadLimiter) GetRetryAfter() int {
+	if l.retryAfter <= 0 {
+		return 5
+	}
+	return l.retryAfter
+}
+
+// Acquire reserves a download slot for the given file.
+// It returns a release function that must be called exactly once when the stream ends,
+// or false when the per-file or global limit is exhausted.
+func (l *DownloadLimiter) Acquire(fileID string) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxPerFile > 0 && l.active[fileID] >= l.maxPerFile {
+		return nil, false
+	}
+	if l.maxTotal > 0 && l.total >= l.maxTotal {
+		return nil, false
+	}
+
+	l.active[fileID]++
+	l.total++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.total--
+			if l.active[fileID]--; l.active[fileID] <= 0 {
+				delete(l.active, fileID)
+			}
+		})
+	}, true
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"strings"
+
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+	"storage-api/internal/utils"
+)
+
+// verifyingReadCloser hashes content as it is streamed and compares it with the stored hash once the
+// end of the content is reached
+type verifyingReadCloser struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected string
+	onResult func(bool)
+	checked  bool
+}
+
+// Read reads from the underlying content, reporting the verification result at EOF
+func (r *verifyingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && !r.checked {
+		r.checked = true
+		r.onResult(hex.EncodeToString(r.hash.Sum(nil)) == r.expected)
+	}
+	return n, err
+}
+
+// IsDownloadVerificationEnabled reports whether downloads are verified against the stored hash while streaming
+func (s *FileService) IsDownloadVerificationEnabled() bool {
+	return s.config.Download.VerifyHash
+}
+
+// NewVerifyingReader wraps a file's content so it is hashed while streamed. onResult is called once
+// with whether the content matched the stored hash, and only if the whole content was read. The
+// content is returned unwrapped when the file's hash algorithm is unsupported.
+func (s *FileService) NewVerifyingReader(rc io.ReadCloser, file *models.File, onResult func(bool)) io.ReadCloser {
+	hasher, err := utils.NewHasher(file.HashAlgorithm)
+	if err != nil {
+		return rc
+	}
+
+	return &verifyingReadCloser{
+		ReadCloser: rc,
+		hash:       hasher,
+		expected:   strings.ToLower(file.Hash),
+		onResult: func(ok bool) {
+			if !ok {
+				s.reportCorruptDownload(file)
+			}
+			onResult(ok)
+		},
+	}
+}
+
+// reportCorruptDownload logs a download whose content didn't match the stored hash and, like the
+// integrity verification, marks the file corrupted when configured to
+func (s *FileService) reportCorruptDownload(file *models.File) {
+	log.Printf("Warning: File %s (%s) failed hash verification during download", file.ID, file.OriginalName)
+	if !s.config.Integrity.AutoMarkCorrupted {
+		return
+	}
+
+	if err := database.WithRetry(func() error {
+		return database.DB.Model(&models.File{}).Where("id = ?", file.ID).Update("status", "corrupted").Error
+	}); err != nil {
+		log.Printf("Warning: Failed to mark file %s as corrupted: %v", file.ID, err)
+	}
+}
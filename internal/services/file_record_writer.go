@@ -0,0 +1,143 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+	"storage-api/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fileRecordWrite is a pending insert and the channel its result is reported on
+type fileRecordWrite struct {
+	file *models.File
+	done chan error
+}
+
+// FileRecordWriter inserts file records, optionally batching concurrent inserts.
+// With batching enabled, records are queued and flushed together once the batch is full or
+// the flush interval elapses; callers block until their own record is written, so IDs and
+// errors are reported per record. A full queue blocks callers, applying backpressure.
+type FileRecordWriter struct {
+	config     config.WriteBatchingConfig
+	idStrategy string
+	queue      chan fileRecordWrite
+	start      sync.Once
+}
+
+// NewFileRecordWriter creates a new file record writer
+func NewFileRecordWriter() *FileRecordWriter {
+	return &FileRecordWriter{
+		config:     config.GetConfig().Storage.WriteBatching,
+		idStrategy: config.GetConfig().Storage.IDs.Strategy,
+	}
+}
+
+// Create inserts a file record, filling in its ID and timestamps
+func (w *FileRecordWriter) Create(file *models.File) error {
+	if err := w.assignID(file); err != nil {
+		return err
+	}
+
+	if !w.config.Enabled {
+		return database.WithRetry(func() error {
+			return database.DB.Create(file).Error
+		})
+	}
+
+	w.start.Do(func() {
+		queueSize := w.config.QueueSize
+		if queueSize <= 0 {
+			queueSize = 1000
+		}
+		w.queue = make(chan fileRecordWrite, queueSize)
+		go w.run()
+	})
+
+	write := fileRecordWrite{file: file, done: make(chan error, 1)}
+	w.queue <- write
+	return <-write.done
+}
+
+// CreateInTransaction inserts a file record within the caller's transaction, bypassing batching
+func (w *FileRecordWriter) CreateInTransaction(tx *gorm.DB, file *models.File) error {
+	if err := w.assignID(file); err != nil {
+		return err
+	}
+	return tx.Create(file).Error
+}
+
+// assignID sets an ID from the configured strategy; the default leaves it to the database
+func (w *FileRecordWriter) assignID(file *models.File) error {
+	if file.ID != uuid.Nil {
+		return nil
+	}
+	id, err := utils.GenerateID(w.idStrategy)
+	if err != nil {
+		return err
+	}
+	file.ID = id
+	return nil
+}
+
+// run collects queued records into batches and flushes them
+func (w *FileRecordWriter) run() {
+	batchSize := w.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+
+	flushInterval := time.Duration(w.config.FlushIntervalMs) * time.Millisecond
+	if flushInterval <= 0 {
+		flushInterval = 20 * time.Millisecond
+	}
+
+	for {
+		// Block until there is work, then gather more until the batch fills or the interval elapses
+		batch := []fileRecordWrite{<-w.queue}
+		timer := time.NewTimer(flushInterval)
+
+	collect:
+		for len(batch) < batchSize {
+			select {
+			case write := <-w.queue:
+				batch = append(batch, write)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		w.flush(batch)
+	}
+}
+
+// flush inserts a batch in submission order. If the batch insert fails, records are
+// retried individually so only the offending records report an error.
+func (w *FileRecordWriter) flush(batch []fileRecordWrite) {
+	files := make([]*models.File, len(batch))
+	for i, write := range batch {
+		files[i] = write.file
+	}
+
+	err := database.WithRetry(func() error {
+		return database.DB.Create(&files).Error
+	})
+	if err == nil {
+		for _, write := range batch {
+			write.done <- nil
+		}
+		return
+	}
+
+	for _, write := range batch {
+		write.done <- database.WithRetry(func() error {
+			return database.DB.Create(write.file).Error
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileReferenceService tracks external references to files, which protect them from deletion
+type FileReferenceService struct {
+	config config.ReferencesConfig
+}
+
+// NewFileReferenceService creates a new file reference service instance
+func NewFileReferenceService() *FileReferenceService {
+	return &FileReferenceService{
+		config: config.GetConfig().Storage.References,
+	}
+}
+
+// IsDeletionProtected reports whether referenced files are protected from deletion
+func (s *FileReferenceService) IsDeletionProtected() bool {
+	return s.config.ProtectDeletion
+}
+
+// AddReference records an external reference to a file. It returns the stored reference and
+// whether it was new; adding a reference that already exists changes nothing. The file row is
+// locked, so the reference can't slip in while the file is being deleted; a missing file returns
+// gorm.ErrRecordNotFound.
+func (s *FileReferenceService) AddReference(fileID uuid.UUID, consumer, reference string) (*models.FileReference, bool, error) {
+	var stored models.FileReference
+	var created bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.File{}, fileID).Error; err != nil {
+			return err
+		}
+
+		stored = models.FileReference{FileID: fileID, Consumer: consumer, Reference: reference}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&stored)
+		if result.Error != nil {
+			return result.Error
+		}
+		if created = result.RowsAffected > 0; created {
+			return nil
+		}
+		return tx.Where("file_id = ? AND consumer = ? AND reference = ?", fileID, consumer, reference).First(&stored).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &stored, created, nil
+}
+
+// RemoveReference removes an external reference to a file, reporting whether it existed. Rows are
+// removed permanently so the unique index doesn't keep the reference from being added again.
+func (s *FileReferenceService) RemoveReference(fileID uuid.UUID, consumer, reference string) (bool, error) {
+	result := database.DB.Unscoped().Where("file_id = ? AND consumer = ? AND reference = ?", fileID, consumer, reference).
+		Delete(&models.FileReference{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListReferences returns the external references to a file, oldest first
+func (s *FileReferenceService) ListReferences(fileID uuid.UUID) ([]models.FileReference, error) {
+	var references []models.FileReference
+	err := database.DB.Where("file_id = ?", fileID).Order("created_at ASC").Find(&references).Error
+	return references, err
+}
+
+// DeleteFileRecord deletes a file's record unless deletion protection is enabled and external
+// references to it exist, in which case nothing is deleted and their number is returned. The file
+// row is locked while references are counted so none can be added in between.
+func (s *FileReferenceService) DeleteFileRecord(file *models.File) (int64, error) {
+	var references int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if s.config.ProtectDeletion {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.File{}, file.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.FileReference{}).Where("file_id = ?", file.ID).Count(&references).Error; err != nil {
+				return err
+			}
+			if references > 0 {
+				return nil
+			}
+		}
+		return tx.Delete(file).Error
+	})
+	return references, err
+}
+
+// DeleteReferences removes all external references to a file
+func (s *FileReferenceService) DeleteReferences(fileID uuid.UUID) error {
+	return database.DB.Unscoped().Where("file_id = ?", fileID).Delete(&models.FileReference{}).Error
+}
@@ -2,42 +2,60 @@ package services
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	"storage-api/internal/config"
 	"storage-api/internal/constants"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
 	"storage-api/internal/utils"
 
 	"github.com/google/uuid"
 	"github.com/kerimovok/go-pkg-utils/errors"
 )
 
+const (
+	// defaultMimeSniffSize is the number of bytes read for MIME type detection when not configured
+	defaultMimeSniffSize = 512
+	// maxMimeSniffSize caps the configurable MIME type detection window
+	maxMimeSniffSize = 1024 * 1024
+)
+
 // FileService handles all file operations and eliminates redundancy
 type FileService struct {
 	config           config.StorageConfig
 	validationEngine *constants.ValidationEngine
+	backends         map[string]StorageBackend
+	deletions        *DeletionService
 }
 
 // NewFileService creates a new file service instance
 func NewFileService() *FileService {
 	storageConfig := config.GetConfig().Storage
+	backends := newStorageBackends(storageConfig.Storage)
 	return &FileService{
 		config:           storageConfig,
 		validationEngine: constants.NewValidationEngine(storageConfig.Validation),
+		backends:         backends,
+		deletions:        &DeletionService{config: storageConfig.Deletion, backends: backends},
 	}
 }
 
 // ValidateFile validates the uploaded file
-func (s *FileService) ValidateFile(file *multipart.FileHeader) error {
-	// Get MIME type from file header
-	mimeType := file.Header.Get("Content-Type")
+func (s *FileService) ValidateFile(file *UploadSource) error {
+	// Get MIME type declared by the client
+	mimeType := file.ContentType
 	if mimeType == "" {
 		mimeType = "application/octet-stream"
 	}
@@ -46,21 +64,110 @@ func (s *FileService) ValidateFile(file *multipart.FileHeader) error {
 	validationResult := s.validationEngine.ValidateFile(file.Filename, mimeType, file.Size)
 
 	if !validationResult.IsAllowed {
-		return errors.BadRequestError("FILE_BLOCKED", validationResult.Reason)
+		code := validationResult.Code
+		if code == "" {
+			code = "FILE_BLOCKED"
+		}
+		return errors.BadRequestError(code, validationResult.Reason)
 	}
 
-	// MIME type validation if enabled
-	if s.config.Validation.StrictMimeValidation {
-		if err := s.validateMimeType(file, validationResult); err != nil {
+	// Content-based validation if enabled
+	if s.config.Validation.StrictMimeValidation || s.config.Validation.StrictExtensionMatch {
+		detectedType, err := s.detectMimeType(file)
+		if err != nil {
 			return err
 		}
+
+		if s.config.Validation.StrictMimeValidation {
+			if err := s.validateMimeType(utils.GetFileExtension(file.Filename), detectedType, validationResult); err != nil {
+				return err
+			}
+		}
+
+		if s.config.Validation.StrictExtensionMatch {
+			if err := s.validateExtensionMatch(utils.GetFileExtension(file.Filename), detectedType); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Enforce the matching rule's image resolution limits
+	if err := s.validateImageDimensions(file, validationResult.MatchedRule); err != nil {
+		return err
+	}
+
+	// Reject archive bombs before anything is stored
+	if err := s.validateArchive(file); err != nil {
+		return err
+	}
+
+	// Reject files that are also valid as script, markup or an archive
+	if err := s.validatePolyglot(file); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 // ValidateMultipleFiles validates multiple uploaded files
-func (s *FileService) ValidateMultipleFiles(files []*multipart.FileHeader) error {
+func (s *FileService) ValidateMultipleFiles(files []*UploadSource) error {
+	if err := s.validateBatchLimits(files); err != nil {
+		return err
+	}
+
+	// Validate each individual file
+	var rejections []FileRejection
+	for _, file := range files {
+		if err := s.ValidateFile(file); err != nil {
+			s.recordRejection(file, err)
+			if !s.config.Upload.AggregateRejections {
+				return err
+			}
+
+			// Keep validating so every rejected file is reported
+			rejections = append(rejections, s.newFileRejection(file, err))
+		}
+	}
+
+	if len(rejections) > 0 {
+		return newBatchValidationError(rejections)
+	}
+	return nil
+}
+
+// FilterValidFiles validates a batch leniently: files failing validation are rejected individually
+// and the rest are returned to be stored. Batch limits still apply to the whole upload, and an error
+// is returned when no file passes validation.
+func (s *FileService) FilterValidFiles(files []*UploadSource) ([]*UploadSource, []FileRejection, error) {
+	if err := s.validateBatchLimits(files); err != nil {
+		return nil, nil, err
+	}
+
+	var valid []*UploadSource
+	var rejections []FileRejection
+	for _, file := range files {
+		if err := s.ValidateFile(file); err != nil {
+			s.recordRejection(file, err)
+			rejections = append(rejections, s.newFileRejection(file, err))
+			continue
+		}
+		valid = append(valid, file)
+	}
+
+	if len(valid) == 0 && len(rejections) > 0 {
+		return nil, rejections, newBatchValidationError(rejections)
+	}
+	return valid, rejections, nil
+}
+
+// newFileRejection describes a validation error for a file, including the rule it matched
+func (s *FileService) newFileRejection(file *UploadSource, err error) FileRejection {
+	rule := s.validationEngine.ValidateFile(file.Filename, file.ContentType, file.Size).RuleName
+	return newFileRejection(file, rule, err)
+}
+
+// validateBatchLimits checks the number of files and total size of an upload batch
+func (s *FileService) validateBatchLimits(files []*UploadSource) error {
 	// Check maximum number of files
 	if len(files) > s.config.Upload.MaxFiles {
 		return errors.BadRequestError("TOO_MANY_FILES", fmt.Sprintf("Maximum %d files allowed per upload", s.config.Upload.MaxFiles))
@@ -83,46 +190,147 @@ func (s *FileService) ValidateMultipleFiles(files []*multipart.FileHeader) error
 		return errors.BadRequestError("TOTAL_SIZE_EXCEEDED", fmt.Sprintf("Total file size %s exceeds limit %s",
 			constants.FormatFileSize(totalSize), s.config.Upload.MaxTotalSize))
 	}
+	return nil
+}
 
-	// Validate each individual file
-	for _, file := range files {
-		if err := s.ValidateFile(file); err != nil {
-			return err
-		}
+// recordRejection stores an audit record for a file blocked by validation, if enabled
+func (s *FileService) recordRejection(file *UploadSource, err error) {
+	if !s.config.RejectionLog.Enabled {
+		return
 	}
 
-	return nil
+	rejection := models.RejectedUpload{
+		FileName:    file.Filename,
+		FileSize:    file.Size,
+		ContentType: file.ContentType,
+		ReasonCode:  "VALIDATION_FAILED",
+		Reason:      err.Error(),
+	}
+	if appErr, ok := err.(*errors.Error); ok {
+		rejection.ReasonCode = appErr.Code
+		rejection.Reason = appErr.Message
+	}
+
+	if err := database.DB.Create(&rejection).Error; err != nil {
+		log.Printf("Warning: Failed to record rejected upload %s: %v", file.Filename, err)
+	}
 }
 
-// validateMimeType validates the MIME type of the file
-func (s *FileService) validateMimeType(file *multipart.FileHeader, validationResult *constants.ValidationResult) error {
+// detectMimeType sniffs the MIME type of the file from its content
+func (s *FileService) detectMimeType(file *UploadSource) (string, error) {
 	// Open file to check MIME type
 	src, err := file.Open()
 	if err != nil {
-		return errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for MIME type validation")
+		return "", errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for MIME type validation")
 	}
 	defer src.Close()
 
-	// Read first 512 bytes for MIME type detection
-	buffer := make([]byte, 512)
-	_, err = src.Read(buffer)
-	if err != nil && err != io.EOF {
-		return errors.InternalError("FILE_READ_ERROR", "Failed to read file for MIME type validation")
+	// Read the start of the file for MIME type detection
+	buffer := make([]byte, s.getMimeSniffSize())
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", errors.InternalError("FILE_READ_ERROR", "Failed to read file for MIME type validation")
+	}
+
+	// Detect MIME type from the bytes actually read, so small files aren't padded with zeros
+	return utils.DetectContentType(buffer[:n]), nil
+}
+
+// resolveMimeType returns the MIME type recorded for an upload and, when detection replaced it, the
+// type the client declared. Only detected types specific enough to map to an extension replace the
+// declared one, so generic content (e.g. text/plain) keeps what the client sent.
+func (s *FileService) resolveMimeType(file *UploadSource) (string, string) {
+	declared := file.ContentType
+	if !s.config.Validation.StoreDetectedMimeType {
+		return declared, ""
+	}
+
+	detected, err := s.detectMimeType(file)
+	if err != nil {
+		log.Printf("Warning: Failed to detect MIME type of %s: %v", file.Filename, err)
+		return declared, ""
+	}
+	detected = strings.Split(detected, ";")[0]
+	if _, known := constants.GetCanonicalExtensions(detected); !known {
+		return declared, ""
+	}
+
+	declaredType := strings.ToLower(strings.TrimSpace(strings.Split(declared, ";")[0]))
+	if declaredType == detected {
+		return declared, ""
+	}
+	return detected, declared
+}
+
+// getMimeSniffSize returns how many bytes are read for MIME type detection
+func (s *FileService) getMimeSniffSize() int64 {
+	size, err := utils.ParseSizeString(s.config.Validation.MimeSniffSize)
+	if err != nil || size <= 0 {
+		return defaultMimeSniffSize
+	}
+	if size > maxMimeSniffSize {
+		return maxMimeSniffSize
 	}
+	return size
+}
 
-	// Detect MIME type
-	detectedType := http.DetectContentType(buffer)
+// validateMimeType validates the detected MIME type of the file
+func (s *FileService) validateMimeType(ext, detectedType string, validationResult *constants.ValidationResult) error {
+	rule := validationResult.MatchedRule
+	if rule == nil {
+		return nil
+	}
 
 	// If we have a matched rule with MIME types, validate against them
-	if validationResult.MatchedRule != nil && len(validationResult.MatchedRule.MimeTypes) > 0 {
-		if err := s.validateMimeTypeAgainstRule(detectedType, validationResult.MatchedRule); err != nil {
-			return err
-		}
+	if len(rule.MimeTypes) > 0 {
+		return s.validateMimeTypeAgainstRule(detectedType, rule)
+	}
+
+	// Otherwise derive the expected types from the file's extension for rules that list extensions
+	if s.config.Validation.DeriveMimeTypes && len(rule.Extensions) > 0 {
+		return s.validateDerivedMimeType(ext, detectedType, rule)
 	}
 
 	return nil
 }
 
+// validateDerivedMimeType validates the detected MIME type against the types known for the file's extension.
+// Extensions without a known type and generic detected content (e.g. text/plain) can't be checked and pass.
+func (s *FileService) validateDerivedMimeType(ext, detectedType string, rule *config.ValidationRule) error {
+	expectedTypes := constants.GetMimeTypesForExtension(ext)
+	if len(expectedTypes) == 0 {
+		return nil
+	}
+	if _, known := constants.GetCanonicalExtensions(detectedType); !known {
+		return nil
+	}
+
+	if !utils.IsValidMimeType(detectedType, expectedTypes) {
+		return errors.BadRequestError("MIME_TYPE_MISMATCH", fmt.Sprintf("Expected MIME type for .%s files matching rule '%s', got %s. Expected types: %s",
+			ext, rule.Name, detectedType, strings.Join(expectedTypes, ", ")))
+	}
+
+	return nil
+}
+
+// validateExtensionMatch ensures the detected content type maps to the file's declared extension
+func (s *FileService) validateExtensionMatch(ext, detectedType string) error {
+	expectedExtensions, known := constants.GetCanonicalExtensions(detectedType)
+	if !known || ext == "" {
+		// Generic or unrecognized content cannot be mapped to an extension
+		return nil
+	}
+
+	for _, expected := range expectedExtensions {
+		if ext == expected {
+			return nil
+		}
+	}
+
+	return errors.BadRequestError("EXTENSION_CONTENT_MISMATCH", fmt.Sprintf("File extension .%s does not match detected content type %s. Expected extensions: %s",
+		ext, detectedType, strings.Join(expectedExtensions, ", ")))
+}
+
 // validateMimeTypeAgainstRule validates MIME type against rule requirements
 func (s *FileService) validateMimeTypeAgainstRule(detectedType string, rule *config.ValidationRule) error {
 	valid := utils.IsValidMimeType(detectedType, rule.MimeTypes)
@@ -136,8 +344,146 @@ func (s *FileService) validateMimeTypeAgainstRule(detectedType string, rule *con
 	return nil
 }
 
-// GenerateFilePath generates the file path based on organization pattern
-func (s *FileService) GenerateFilePath(originalName, fileType string) (string, string, error) {
+// DeriveFileType returns the file type recorded for a file name: the category of the validation
+// rule it matches when rule-based file types are enabled, otherwise its extension
+func (s *FileService) DeriveFileType(fileName string) string {
+	ext := utils.GetFileExtension(fileName)
+	if !s.config.Organization.FileTypeFromRule {
+		return ext
+	}
+
+	validationResult := s.validationEngine.ValidateFile(fileName, "", 0)
+	if validationResult.MatchedRule == nil {
+		return ext
+	}
+
+	// Like category tags, the rule's tag names the category and its name is the fallback
+	category := validationResult.MatchedRule.Tag
+	if category == "" {
+		category = validationResult.MatchedRule.Name
+	}
+	if category = strings.ToLower(strings.TrimSpace(category)); category == "" {
+		return ext
+	}
+	return category
+}
+
+// ResolveBackend returns the storage backend configured for a file's category.
+// The category is the name of the validation rule the file name matches.
+func (s *FileService) ResolveBackend(fileName string) StorageBackend {
+	validationResult := s.validationEngine.ValidateFile(fileName, "", 0)
+	if validationResult.MatchedRule != nil {
+		if name, ok := s.config.Storage.CategoryBackends[validationResult.MatchedRule.Name]; ok {
+			if backend, ok := s.backends[name]; ok {
+				return backend
+			}
+			log.Printf("Warning: Unknown storage backend %q for category %q, using default", name, validationResult.MatchedRule.Name)
+		}
+	}
+	return s.backends[DefaultBackendName]
+}
+
+// GetBackend returns the named backend, falling back to the default for unknown names
+func (s *FileService) GetBackend(name string) StorageBackend {
+	if backend, ok := s.backends[name]; ok {
+		return backend
+	}
+	return s.backends[DefaultBackendName]
+}
+
+// CopyToTemp copies a file's stored content into a temporary file for tools that need a local
+// path. The caller removes the copy when done.
+func (s *FileService) CopyToTemp(file *models.File) (string, error) {
+	src, err := s.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmpFile, err := os.CreateTemp("", "storage-content-*."+file.Extension)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmpFile, src)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
+
+// GetStorageLocation describes the backend holding a file
+func (s *FileService) GetStorageLocation(file *models.File) *models.StorageLocation {
+	backend := s.GetBackend(file.Backend)
+	return &models.StorageLocation{
+		Backend: backend.Name(),
+		Type:    backend.Type(),
+		BaseDir: backend.BaseDir(),
+	}
+}
+
+// GetInlineContentMaxSize returns the largest file size whose content may be embedded in
+// metadata responses; 0 means embedding is disabled
+func (s *FileService) GetInlineContentMaxSize() int64 {
+	size, err := utils.ParseSizeString(s.config.Metadata.InlineContentMaxSize)
+	if err != nil || size < 0 {
+		return 0
+	}
+	return size
+}
+
+// ReadInlineContent returns a file's content base64-encoded for embedding in a metadata response
+func (s *FileService) ReadInlineContent(file *models.File) (string, error) {
+	src, err := s.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, file.FileSize))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// IsStorageLocationExposed reports whether admins may see storage locations in metadata responses
+func (s *FileService) IsStorageLocationExposed() bool {
+	return s.config.Metadata.ExposeStorageLocation
+}
+
+// IsRequestIDRecorded reports whether the request ID of the creating upload is stored with each file
+func (s *FileService) IsRequestIDRecorded() bool {
+	return s.config.Metadata.RecordRequestID
+}
+
+// IsMetadataETagEnabled reports whether metadata responses carry a weak ETag
+func (s *FileService) IsMetadataETagEnabled() bool {
+	return s.config.Metadata.WeakETag
+}
+
+// IsUploaderRecorded reports whether the client IP and User-Agent of each upload are stored
+func (s *FileService) IsUploaderRecorded() bool {
+	return s.config.Clients.RecordUploader
+}
+
+// DeleteStoredFile removes a stored file and any kept original from the backend holding it
+// Failed removals are retried, and paths that still can't be removed are queued for garbage collection.
+func (s *FileService) DeleteStoredFile(backendName, filePath, originalFilePath string) error {
+	err := s.deletions.Delete(backendName, filePath)
+	if originalFilePath != "" {
+		if originalErr := s.deletions.Delete(backendName, originalFilePath); err == nil {
+			err = originalErr
+		}
+	}
+	return err
+}
+
+// GenerateFilePath generates the file path within a backend based on organization pattern
+func (s *FileService) GenerateFilePath(backend StorageBackend, originalName, fileType string) (string, string, error) {
 	var pathParts []string
 
 	// Add date component
@@ -151,7 +497,7 @@ func (s *FileService) GenerateFilePath(originalName, fileType string) (string, s
 
 	// Add file type component
 	if strings.Contains(s.config.Organization.Pattern, "type") {
-		pathParts = append(pathParts, fileType)
+		pathParts = append(pathParts, s.typePathSegment(originalName, fileType))
 	}
 
 	// Generate file name
@@ -162,16 +508,39 @@ func (s *FileService) GenerateFilePath(originalName, fileType string) (string, s
 
 	// Combine path
 	filePath := filepath.Join(pathParts...)
-	fullPath := filepath.Join(s.config.Storage.UploadDir, filePath, fileName)
+	fullPath := filepath.Join(backend.BaseDir(), filePath, fileName)
 
 	return fullPath, fileName, nil
 }
 
+// typePathSegment returns the directory for the 'type' path token. Files without a type, or that
+// match no rule when types come from rules, share the configured fallback directory.
+func (s *FileService) typePathSegment(originalName, fileType string) string {
+	if fileType == "" {
+		return s.config.Organization.TypeFallback
+	}
+	if s.config.Organization.FileTypeFromRule && s.validationEngine.ValidateFile(originalName, "", 0).MatchedRule == nil {
+		return s.config.Organization.TypeFallback
+	}
+	return fileType
+}
+
 // generateFileName generates a unique file name
 func (s *FileService) generateFileName(originalName string) (string, error) {
-	ext := filepath.Ext(originalName)
+	// Generated names carry the canonical extension, so "photo.JPG" is stored as "<id>.jpg"
+	ext := ""
+	if canonical := utils.GetFileExtension(originalName); canonical != "" {
+		ext = "." + canonical
+	}
 
-	switch s.config.Organization.Naming.Strategy {
+	strategy := s.config.Organization.Naming.Strategy
+	if !config.IsSupportedNamingStrategy(strategy) {
+		// Config load rejects unknown strategies; fall back rather than failing every upload
+		log.Printf("Warning: Unsupported file naming strategy '%s', using 'uuid'", strategy)
+		strategy = "uuid"
+	}
+
+	switch strategy {
 	case "uuid":
 		id, err := uuid.NewRandom()
 		if err != nil {
@@ -190,33 +559,25 @@ func (s *FileService) generateFileName(originalName string) (string, error) {
 		return fmt.Sprintf("%d", timestamp), nil
 
 	case "original":
+		name := originalName
+		if s.config.Organization.Naming.Transliterate {
+			name = utils.TransliterateFilename(name)
+		}
+		// Strip the name's own extension, whatever its case; only the canonical one is kept
+		name = strings.TrimRight(name, ". ")
+		name = strings.TrimSuffix(name, filepath.Ext(name))
 		if s.config.Organization.Naming.PreserveExtension {
-			return originalName, nil
+			return name + ext, nil
 		}
-		return strings.TrimSuffix(originalName, ext), nil
+		return name, nil
 
 	default:
 		return "", errors.InternalError("INVALID_NAMING_STRATEGY", "Invalid file naming strategy")
 	}
 }
 
-// SaveFile saves the uploaded file to storage
-func (s *FileService) SaveFile(file *multipart.FileHeader, filePath string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filePath)
-	if s.config.Storage.CreateDirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return errors.InternalError("DIR_CREATION_ERROR", fmt.Sprintf("Failed to create directory: %v", err))
-		}
-	}
-
-	// Create destination file
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return errors.InternalError("FILE_CREATION_ERROR", fmt.Sprintf("Failed to create destination file: %v", err))
-	}
-	defer dst.Close()
-
+// SaveFile saves the uploaded file to the given storage backend
+func (s *FileService) SaveFile(backend StorageBackend, file *UploadSource, filePath string) error {
 	// Open source file
 	src, err := file.Open()
 	if err != nil {
@@ -224,25 +585,26 @@ func (s *FileService) SaveFile(file *multipart.FileHeader, filePath string) erro
 	}
 	defer src.Close()
 
-	// Copy file content
-	if _, err = io.Copy(dst, src); err != nil {
-		return errors.InternalError("FILE_COPY_ERROR", fmt.Sprintf("Failed to copy file content: %v", err))
-	}
-
-	return nil
+	return backend.Save(src, filePath)
 }
 
 // ProcessMultipleFiles processes multiple uploaded files
-func (s *FileService) ProcessMultipleFiles(files []*multipart.FileHeader) ([]*FileUploadResult, error) {
+func (s *FileService) ProcessMultipleFiles(files []*UploadSource) ([]*FileUploadResult, error) {
 	var results []*FileUploadResult
 
+	// First stored copy of each content hash in this batch
+	batchCopies := make(map[string]*FileUploadResult)
+
 	for _, file := range files {
-		// Determine file type from extension
-		ext := utils.GetFileExtensionFromHeader(file)
-		fileType := ext
+		// Determine file type from the matching rule or the extension
+		ext := utils.GetFileExtension(file.Filename)
+		fileType := s.DeriveFileType(file.Filename)
+
+		// Resolve the backend for the file's category
+		backend := s.ResolveBackend(file.Filename)
 
 		// Generate file path and name
-		filePath, storedName, err := s.GenerateFilePath(file.Filename, fileType)
+		filePath, storedName, err := s.GenerateFilePath(backend, file.Filename, fileType)
 		if err != nil {
 			results = append(results, &FileUploadResult{
 				OriginalName: file.Filename,
@@ -253,7 +615,7 @@ func (s *FileService) ProcessMultipleFiles(files []*multipart.FileHeader) ([]*Fi
 		}
 
 		// Save file to storage
-		if err := s.SaveFile(file, filePath); err != nil {
+		if err := s.SaveFile(backend, file, filePath); err != nil {
 			results = append(results, &FileUploadResult{
 				OriginalName: file.Filename,
 				Success:      false,
@@ -262,8 +624,34 @@ func (s *FileService) ProcessMultipleFiles(files []*multipart.FileHeader) ([]*Fi
 			continue
 		}
 
+		fileSize := file.Size
+		mimeType, declaredMimeType := s.resolveMimeType(file)
+		originalFilePath := ""
+
+		// Convert HEIC images to JPEG if enabled
+		if s.shouldConvertHEIC(filePath) {
+			conversion, err := s.ConvertHEICToJPEG(filePath, s.ShouldKeepOriginal(file.Filename, s.config.HEICConversion.KeepOriginal))
+			if err != nil {
+				backend.Delete(filePath)
+				results = append(results, &FileUploadResult{
+					OriginalName: file.Filename,
+					Success:      false,
+					Error:        err.Error(),
+				})
+				continue
+			}
+
+			filePath = conversion.FilePath
+			storedName = filepath.Base(conversion.FilePath)
+			fileSize = conversion.Size
+			mimeType = "image/jpeg"
+			ext = "jpg"
+			fileType = s.DeriveFileType(storedName)
+			originalFilePath = conversion.OriginalFilePath
+		}
+
 		// Calculate file hash
-		hash, err := s.CalculateFileHash(filePath)
+		hash, err := s.CalculateFileHash(backend.Name(), filePath)
 		if err != nil {
 			results = append(results, &FileUploadResult{
 				OriginalName: file.Filename,
@@ -273,48 +661,216 @@ func (s *FileService) ProcessMultipleFiles(files []*multipart.FileHeader) ([]*Fi
 			continue
 		}
 
+		// Identical content is never stored twice, since hashes are unique. With deduplication the
+		// upload reuses the existing file; without it the upload is reported as a duplicate.
+		existing, err := s.FindFileByHash(hash, s.GetHashAlgorithm())
+		if err != nil {
+			s.DeleteStoredFile(backend.Name(), filePath, originalFilePath)
+			results = append(results, &FileUploadResult{
+				OriginalName: file.Filename,
+				Success:      false,
+				Error:        err.Error(),
+			})
+			continue
+		}
+		if existing != nil {
+			if err := s.DeleteStoredFile(backend.Name(), filePath, originalFilePath); err != nil {
+				log.Printf("Warning: Failed to remove duplicate upload %s: %v", filePath, err)
+			}
+			if s.config.Deduplication.Enabled {
+				results = append(results, &FileUploadResult{
+					OriginalName:   file.Filename,
+					StoredName:     existing.StoredName,
+					FileSize:       existing.FileSize,
+					MimeType:       existing.MimeType,
+					Extension:      existing.Extension,
+					FileType:       existing.FileType,
+					Hash:           existing.Hash,
+					HashAlgorithm:  existing.HashAlgorithm,
+					Deduplicated:   true,
+					ExistingFileID: existing.ID.String(),
+					Success:        true,
+				})
+			} else {
+				results = append(results, NewDuplicateFileResult(file.Filename, existing))
+			}
+			continue
+		}
+
+		// Content repeated within the batch isn't stored twice
+		if first, ok := batchCopies[hash]; ok {
+			if err := s.DeleteStoredFile(backend.Name(), filePath, originalFilePath); err != nil {
+				log.Printf("Warning: Failed to remove duplicate upload %s: %v", filePath, err)
+			}
+			results = append(results, s.newBatchDuplicateResult(file.Filename, first))
+			continue
+		}
+
+		// Calculate perceptual hash for images if enabled
+		perceptualHash := s.CalculatePerceptualHash(backend.Name(), filePath, ext)
+
 		// Add successful result
-		results = append(results, &FileUploadResult{
-			OriginalName: file.Filename,
-			StoredName:   storedName,
-			FilePath:     filePath,
-			FileSize:     file.Size,
-			MimeType:     file.Header.Get("Content-Type"),
-			Extension:    ext,
-			FileType:     fileType,
-			Hash:         hash,
-			Success:      true,
-		})
+		result := &FileUploadResult{
+			OriginalName:     file.Filename,
+			StoredName:       storedName,
+			FilePath:         filePath,
+			Backend:          backend.Name(),
+			FileSize:         fileSize,
+			MimeType:         mimeType,
+			DeclaredMimeType: declaredMimeType,
+			Extension:        ext,
+			FileType:         fileType,
+			Hash:             hash,
+			HashAlgorithm:    s.GetHashAlgorithm(),
+			PerceptualHash:   perceptualHash,
+			OriginalFilePath: originalFilePath,
+			Success:          true,
+		}
+		batchCopies[hash] = result
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
+// newBatchDuplicateResult returns the result for a file whose content matches a file stored earlier
+// in the same batch. Depending on the configuration it either fails or, like a deduplicated upload,
+// refers to the record created for the first copy.
+func (s *FileService) newBatchDuplicateResult(originalName string, first *FileUploadResult) *FileUploadResult {
+	if s.config.Upload.GetBatchDuplicates() == "merge" {
+		return &FileUploadResult{
+			OriginalName:     originalName,
+			StoredName:       first.StoredName,
+			FileSize:         first.FileSize,
+			MimeType:         first.MimeType,
+			Extension:        first.Extension,
+			FileType:         first.FileType,
+			Hash:             first.Hash,
+			HashAlgorithm:    first.HashAlgorithm,
+			Deduplicated:     true,
+			BatchDuplicateOf: first,
+			Success:          true,
+		}
+	}
+
+	return &FileUploadResult{
+		OriginalName:     originalName,
+		Hash:             first.Hash,
+		HashAlgorithm:    first.HashAlgorithm,
+		Duplicate:        true,
+		BatchDuplicateOf: first,
+		Success:          false,
+		Error:            fmt.Sprintf("Identical content to %s earlier in the same upload", first.OriginalName),
+	}
+}
+
 // FileUploadResult contains the result of processing a single file
 type FileUploadResult struct {
-	OriginalName string `json:"original_name"`
-	StoredName   string `json:"stored_name,omitempty"`
-	FilePath     string `json:"file_path,omitempty"`
-	FileSize     int64  `json:"file_size,omitempty"`
-	MimeType     string `json:"mime_type,omitempty"`
-	Extension    string `json:"extension,omitempty"`
-	FileType     string `json:"file_type,omitempty"`
-	Hash         string `json:"hash,omitempty"`
-	Success      bool   `json:"success"`
-	Error        string `json:"error,omitempty"`
-}
-
-// CalculateFileHash calculates MD5 hash of the file
-func (s *FileService) CalculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+	OriginalName     string `json:"original_name"`
+	StoredName       string `json:"stored_name,omitempty"`
+	FilePath         string `json:"file_path,omitempty"`
+	Backend          string `json:"backend,omitempty"`
+	FileSize         int64  `json:"file_size,omitempty"`
+	MimeType         string `json:"mime_type,omitempty"`
+	DeclaredMimeType string `json:"declared_mime_type,omitempty"`
+	Extension        string `json:"extension,omitempty"`
+	FileType         string `json:"file_type,omitempty"`
+	Hash             string `json:"hash,omitempty"`
+	HashAlgorithm    string `json:"hash_algorithm,omitempty"`
+	PerceptualHash   string `json:"perceptual_hash,omitempty"`
+	OriginalFilePath string `json:"-"`
+	Deduplicated     bool   `json:"deduplicated,omitempty"`
+	Duplicate        bool   `json:"duplicate,omitempty"`
+	ExistingFileID   string `json:"existing_file_id,omitempty"`
+	// BatchDuplicateOf is the result of the first copy of content repeated within a batch, and
+	// RecordID the ID of the record created for a stored result
+	BatchDuplicateOf *FileUploadResult `json:"-"`
+	RecordID         string            `json:"-"`
+	Success          bool              `json:"success"`
+	Error            string            `json:"error,omitempty"`
+}
+
+// DuplicateFileErrorCode is reported for uploads whose content is already stored while deduplication is disabled
+const DuplicateFileErrorCode = "DUPLICATE_FILE"
+
+// BatchDuplicateErrorCode is reported for files whose content appears earlier in the same upload batch
+const BatchDuplicateErrorCode = "DUPLICATE_IN_BATCH"
+
+// NewDuplicateFileResult returns the failed result for an upload whose content matches an existing file
+func NewDuplicateFileResult(originalName string, existing *models.File) *FileUploadResult {
+	return &FileUploadResult{
+		OriginalName:   originalName,
+		Hash:           existing.Hash,
+		HashAlgorithm:  existing.HashAlgorithm,
+		Duplicate:      true,
+		ExistingFileID: existing.ID.String(),
+		Success:        false,
+		Error:          "A file with identical content already exists",
+	}
+}
+
+// NormalizeOriginalName applies the configured Unicode normalization to a client-supplied file name.
+// It returns the name to store and, when normalization changed it, the raw name.
+func (s *FileService) NormalizeOriginalName(name string) (string, string) {
+	if !s.config.Organization.Naming.NormalizeUnicode {
+		return name, ""
+	}
+	if normalized, changed := utils.NormalizeFilenameNFC(name); changed {
+		return normalized, name
+	}
+	return name, ""
+}
+
+// ShouldKeepOriginal reports whether the untouched original should be kept after a transformation.
+// A keep_original setting on the matching validation rule wins; otherwise the transformation's own
+// setting or the global transformations.keep_original flag keeps it.
+func (s *FileService) ShouldKeepOriginal(fileName string, transformationDefault bool) bool {
+	validationResult := s.validationEngine.ValidateFile(fileName, "", 0)
+	if validationResult.MatchedRule != nil && validationResult.MatchedRule.KeepOriginal != nil {
+		return *validationResult.MatchedRule.KeepOriginal
+	}
+	return transformationDefault || s.config.Transformations.KeepOriginal
+}
+
+// FindFileByHash returns the file with the given content hash, or nil if there is none
+func (s *FileService) FindFileByHash(hash, algorithm string) (*models.File, error) {
+	var files []models.File
+	if err := database.DB.Where("hash = ? AND hash_algorithm = ?", hash, algorithm).Limit(1).Find(&files).Error; err != nil {
+		return nil, errors.InternalError("DATABASE_ERROR", fmt.Sprintf("Failed to look up duplicate file: %v", err))
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return &files[0], nil
+}
+
+// GetHashAlgorithm returns the configured content hash algorithm
+func (s *FileService) GetHashAlgorithm() string {
+	algorithm := utils.NormalizeHashAlgorithm(s.config.Hashing.Algorithm)
+	if algorithm == "" {
+		return "md5"
+	}
+	return algorithm
+}
+
+// CalculateFileHash calculates the hash of a file held by the named backend using the configured algorithm
+func (s *FileService) CalculateFileHash(backendName, filePath string) (string, error) {
+	return s.CalculateFileHashWithAlgorithm(backendName, filePath, s.GetHashAlgorithm())
+}
+
+// CalculateFileHashWithAlgorithm calculates the hash of a file held by the named backend using the given algorithm
+func (s *FileService) CalculateFileHashWithAlgorithm(backendName, filePath, algorithm string) (string, error) {
+	hash, err := utils.NewHasher(algorithm)
+	if err != nil {
+		return "", errors.BadRequestError("UNSUPPORTED_HASH_ALGORITHM", err.Error())
+	}
+
+	file, err := s.GetBackend(backendName).Open(filePath)
 	if err != nil {
 		return "", errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for hash calculation")
 	}
 	defer file.Close()
 
-	// Create MD5 hash
-	hash := md5.New()
-
 	// Copy file content to hash
 	if _, err := io.Copy(hash, file); err != nil {
 		return "", errors.InternalError("HASH_CALCULATION_ERROR", fmt.Sprintf("Failed to calculate hash: %v", err))
@@ -325,6 +881,232 @@ func (s *FileService) CalculateFileHash(filePath string) (string, error) {
 	return fmt.Sprintf("%x", hashBytes), nil
 }
 
+// perceptualHashExtensions lists image extensions that can be decoded for perceptual hashing
+var perceptualHashExtensions = map[string]bool{
+	"jpg":  true,
+	"jpeg": true,
+	"png":  true,
+	"gif":  true,
+}
+
+// IsPerceptualHashEnabled reports whether perceptual hashing of images is enabled
+func (s *FileService) IsPerceptualHashEnabled() bool {
+	return s.config.PerceptualHash.Enabled
+}
+
+// GetSimilarityThreshold returns the maximum Hamming distance for similar images
+func (s *FileService) GetSimilarityThreshold() int {
+	if s.config.PerceptualHash.Threshold <= 0 {
+		return 10
+	}
+	return s.config.PerceptualHash.Threshold
+}
+
+// CalculatePerceptualHash computes the perceptual hash of an image file held by the named backend.
+// It returns an empty string when hashing is disabled, the file is not a supported image or decoding fails.
+func (s *FileService) CalculatePerceptualHash(backendName, filePath, extension string) string {
+	if !s.config.PerceptualHash.Enabled || !perceptualHashExtensions[extension] {
+		return ""
+	}
+
+	file, err := s.GetBackend(backendName).Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to open %s for perceptual hashing: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hash, err := utils.ComputeDifferenceHash(file)
+	if err != nil {
+		log.Printf("Warning: Failed to compute perceptual hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return utils.FormatPerceptualHash(hash)
+}
+
+// IsDigestHeaderEnabled reports whether downloads advertise the stored content digest
+func (s *FileService) IsDigestHeaderEnabled() bool {
+	return s.config.Download.DigestHeaders
+}
+
+// IsStrongETagEnabled reports whether downloads carry a strong content ETag
+func (s *FileService) IsStrongETagEnabled() bool {
+	return s.config.Download.StrongETag
+}
+
+// activeContentTypes lists MIME types that browsers may execute scripts from when rendered inline
+var activeContentTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+	"text/xml":              true,
+	"application/xml":       true,
+}
+
+// activeContentExtensions lists extensions treated as active content regardless of detected MIME type
+var activeContentExtensions = map[string]bool{
+	"html":  true,
+	"htm":   true,
+	"xhtml": true,
+	"svg":   true,
+	"svgz":  true,
+	"xml":   true,
+}
+
+// IsActiveContent reports whether a file could run scripts if rendered by a browser.
+// Both the detected type and any content-type override are considered.
+func IsActiveContent(file *models.File) bool {
+	for _, contentType := range []string{file.MimeType, file.ContentTypeOverride} {
+		mimeType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+		if activeContentTypes[mimeType] {
+			return true
+		}
+	}
+	return activeContentExtensions[strings.ToLower(file.Extension)]
+}
+
+// ServedContentType returns the Content-Type to send instead of the one derived from the file's
+// extension, or "" to keep it: the client-set override, or the detected type when detection
+// corrected the declared one at upload
+func ServedContentType(file *models.File) string {
+	if file.ContentTypeOverride != "" {
+		return file.ContentTypeOverride
+	}
+	if file.DeclaredMimeType != "" {
+		return file.MimeType
+	}
+	return ""
+}
+
+// CanServeInline reports whether a file may be served with an inline disposition
+func (s *FileService) CanServeInline(file *models.File) bool {
+	return s.config.Download.AllowInlineActiveContent || !IsActiveContent(file)
+}
+
+// GetContentSecurityPolicy returns the policy applied to user content served inline
+func (s *FileService) GetContentSecurityPolicy() string {
+	if s.config.Download.ContentSecurityPolicy == "" {
+		return "default-src 'none'; img-src 'self' data:; media-src 'self'; style-src 'unsafe-inline'; sandbox"
+	}
+	return s.config.Download.ContentSecurityPolicy
+}
+
+// DownloadFileName resolves the configured download name template for a file. The result is
+// used in Content-Disposition, so characters that could break out of the header are removed.
+func (s *FileService) DownloadFileName(file *models.File) string {
+	name := file.OriginalName
+	if template := s.config.Download.FilenameTemplate; template != "" {
+		ext := strings.TrimPrefix(filepath.Ext(file.OriginalName), ".")
+		if ext == "" {
+			ext = file.Extension
+		}
+		name = strings.NewReplacer(
+			"{name}", file.OriginalName,
+			"{original}", strings.TrimSuffix(file.OriginalName, filepath.Ext(file.OriginalName)),
+			"{ext}", ext,
+			"{date}", file.CreatedAt.Format("2006-01-02"),
+			"{id}", file.ID.String(),
+			"{hash}", file.Hash,
+		).Replace(template)
+	}
+
+	if sanitized := sanitizeDownloadName(name); sanitized != "" {
+		return sanitized
+	}
+	if sanitized := sanitizeDownloadName(file.OriginalName); sanitized != "" {
+		return sanitized
+	}
+	return file.ID.String()
+}
+
+// ContentDisposition returns the Content-Disposition header value for serving a file inline or as
+// an attachment under its download name
+func (s *FileService) ContentDisposition(disposition string, file *models.File) string {
+	encodeUTF8 := !strings.EqualFold(s.config.Download.FilenameEncoding, "ascii")
+	return utils.ContentDisposition(disposition, s.DownloadFileName(file), encodeUTF8)
+}
+
+// sanitizeDownloadName drops control characters (including CR/LF) and replaces quotes and
+// path separators, then trims leftover separators such as a dangling "." from an empty {ext}
+func sanitizeDownloadName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return -1
+		case r == '"' || r == '\\' || r == '/':
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.Trim(name, " .-_")
+}
+
+// ContentETag builds a strong, quoted entity tag from a file's content hash and size.
+// The hash algorithm is included so tags from different algorithms can never collide.
+func ContentETag(file *models.File) string {
+	algorithm := file.HashAlgorithm
+	if algorithm == "" {
+		algorithm = "md5"
+	}
+	return fmt.Sprintf(`"%s-%s-%d"`, algorithm, file.Hash, file.FileSize)
+}
+
+// MetadataETag builds a weak entity tag for a file's metadata response. Metadata changes without the
+// content changing, so it is derived from the last update and the tags (which don't touch the file
+// record), plus the response variant, e.g. the requested includes.
+func MetadataETag(file *models.File, variant string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s", file.ID, file.UpdatedAt.UnixNano(), strings.Join(file.Tags, ","), variant)))
+	return fmt.Sprintf(`W/"%x"`, sum[:12])
+}
+
+// SearchResultsETag builds a weak entity tag for a page of search results. It is derived from a
+// fingerprint of all files matching the filters rather than the page itself, so revalidating costs
+// aggregate queries instead of loading the page, plus the response variant, e.g. the query string.
+func SearchResultsETag(fingerprint, variant string) string {
+	sum := sha256.Sum256([]byte(fingerprint + "|" + variant))
+	return fmt.Sprintf(`W/"%x"`, sum[:12])
+}
+
+// digestAlgorithms maps stored hash algorithms to their RFC 3230 digest algorithm names
+var digestAlgorithms = map[string]string{
+	"md5":    "MD5",
+	"sha1":   "SHA",
+	"sha256": "SHA-256",
+	"sha512": "SHA-512",
+}
+
+// ContentDigest builds an RFC 3230 Digest header value (e.g. "SHA-256=<base64>") from a file's
+// stored hash, reporting false when the hash can't be expressed as a digest
+func ContentDigest(file *models.File) (string, bool) {
+	algorithm := file.HashAlgorithm
+	if algorithm == "" {
+		algorithm = "md5"
+	}
+	name, ok := digestAlgorithms[algorithm]
+	if !ok {
+		return "", false
+	}
+
+	sum, err := hex.DecodeString(file.Hash)
+	if err != nil || len(sum) == 0 {
+		return "", false
+	}
+	return name + "=" + base64.StdEncoding.EncodeToString(sum), true
+}
+
+// ContentMD5 returns the base64 Content-MD5 header value for files whose stored hash is MD5
+func ContentMD5(file *models.File) (string, bool) {
+	if file.HashAlgorithm != "" && file.HashAlgorithm != "md5" {
+		return "", false
+	}
+	sum, err := hex.DecodeString(file.Hash)
+	if err != nil || len(sum) != md5.Size {
+		return "", false
+	}
+	return base64.StdEncoding.EncodeToString(sum), true
+}
+
 // GetMaxFileSizeForExtension returns the maximum allowed file size for a specific extension
 func (s *FileService) GetMaxFileSizeForExtension(extension string) int64 {
 	// Use validation engine to get max size
@@ -368,6 +1150,11 @@ func (s *FileService) GetBlockedExtensions() []string {
 	return s.validationEngine.GetBlockedExtensions()
 }
 
+// GetExtensionDetails returns the effective validation rule of every extension the rules name
+func (s *FileService) GetExtensionDetails() []constants.ExtensionDetail {
+	return s.validationEngine.GetExtensionDetails()
+}
+
 // GetValidationRules returns all validation rules
 func (s *FileService) GetValidationRules() []config.ValidationRule {
 	return s.config.Validation.Rules
@@ -388,6 +1175,22 @@ func (s *FileService) GetValidationConfig() config.FileValidationConfig {
 	return s.config.Validation
 }
 
+// GetSearchConfig returns the search configuration
+func (s *FileService) GetSearchConfig() config.SearchConfig {
+	return s.config.Search
+}
+
+// GetMultipartMemory returns how many bytes of multipart file parts are buffered in memory per
+// request before spilling to temporary files
+func (s *FileService) GetMultipartMemory() int64 {
+	if s.config.Upload.MultipartMemory != "" {
+		if size, err := utils.ParseSizeString(s.config.Upload.MultipartMemory); err == nil {
+			return size
+		}
+	}
+	return 16 * 1024 * 1024 // 16MB
+}
+
 // GetUploadConfig returns the upload configuration
 func (s *FileService) GetUploadConfig() config.UploadConfig {
 	return s.config.Upload
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateExtensionMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		ext          string
+		detectedType string
+		wantErr      bool
+	}{
+		{"matching", "png", "image/png", false},
+		{"alternate extension", "jpeg", "image/jpeg", false},
+		{"content type parameters", "pdf", "application/pdf; charset=binary", false},
+		{"mismatch", "png", "image/jpeg", true},
+		{"disguised document", "jpg", "application/pdf", true},
+		{"unknown content type", "dat", "application/x-unknown", false},
+		{"no extension", "", "image/png", false},
+	}
+
+	s := &FileService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateExtensionMatch(tt.ext, tt.detectedType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateExtensionMatch(%q, %q) error = %v, want error %v", tt.ext, tt.detectedType, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateFileNameOriginal(t *testing.T) {
+	tests := []struct {
+		originalName      string
+		preserveExtension bool
+		want              string
+	}{
+		{"report.pdf", true, "report.pdf"},
+		{"report.pdf", false, "report"},
+		{"Photo.JPG", true, "Photo.jpg"},
+		{"Photo.JPG", false, "Photo"},
+		{"scan.jpeg", false, "scan"},
+		{"archive.tar.GZ", false, "archive.tar"},
+		{"notes.txt.", false, "notes"},
+		{"README", true, "README"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.originalName, func(t *testing.T) {
+			s := &FileService{config: config.StorageConfig{Organization: config.StorageOrganizationConfig{
+				Naming: config.FileNamingConfig{Strategy: "original", PreserveExtension: tt.preserveExtension},
+			}}}
+			got, err := s.generateFileName(tt.originalName)
+			if err != nil {
+				t.Fatalf("generateFileName(%q) error = %v", tt.originalName, err)
+			}
+			if got != tt.want {
+				t.Errorf("generateFileName(%q) = %q, want %q", tt.originalName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyToTemp(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileService{backends: map[string]StorageBackend{
+		DefaultBackendName: NewLocalBackend(DefaultBackendName, dir, false, false),
+	}}
+	file := &models.File{FilePath: filepath.Join(dir, "stored.pdf"), Extension: "pdf"}
+	if err := os.WriteFile(file.FilePath, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := s.CopyToTemp(file)
+	if err != nil {
+		t.Fatalf("CopyToTemp() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	if path == file.FilePath || filepath.Ext(path) != ".pdf" {
+		t.Errorf("CopyToTemp() = %s, want a separate .pdf copy", path)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("copy holds %q (%v), want the stored content", data, err)
+	}
+
+	file.FilePath = filepath.Join(dir, "missing.pdf")
+	if _, err := s.CopyToTemp(file); !os.IsNotExist(err) {
+		t.Errorf("CopyToTemp() of a missing file error = %v, want not exist", err)
+	}
+}
+
+// Hashes of empty content
+const (
+	emptyMD5    = "d41d8cd98f00b204e9800998ecf8427e"
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestContentETag(t *testing.T) {
+	tests := []struct {
+		name string
+		file models.File
+		want string
+	}{
+		{"md5", models.File{Hash: emptyMD5, HashAlgorithm: "md5", FileSize: 0}, `"md5-` + emptyMD5 + `-0"`},
+		{"default algorithm", models.File{Hash: emptyMD5, FileSize: 12}, `"md5-` + emptyMD5 + `-12"`},
+		{"sha256", models.File{Hash: emptySHA256, HashAlgorithm: "sha256", FileSize: 3}, `"sha256-` + emptySHA256 + `-3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentETag(&tt.file); got != tt.want {
+				t.Errorf("ContentETag() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGzipETag(t *testing.T) {
+	if got, want := GzipETag(`"md5-abc-3"`), `"md5-abc-3-gzip"`; got != want {
+		t.Errorf("GzipETag() = %s, want %s", got, want)
+	}
+}
+
+func TestSanitizeDownloadName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "report.pdf", "report.pdf"},
+		{"header injection", "report\r\nSet-Cookie: x.pdf", "reportSet-Cookie: x.pdf"},
+		{"quotes", `say "hi".txt`, "say _hi_.txt"},
+		{"path separators", `../etc\passwd`, "etc_passwd"},
+		{"dangling extension dot", "report.", "report"},
+		{"only separators", " ._- ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeDownloadName(tt.input); got != tt.want {
+				t.Errorf("sanitizeDownloadName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentDigest(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   models.File
+		want   string
+		wantOK bool
+	}{
+		{"md5", models.File{Hash: emptyMD5, HashAlgorithm: "md5"}, "MD5=1B2M2Y8AsgTpgAmY7PhCfg==", true},
+		{"default algorithm", models.File{Hash: emptyMD5}, "MD5=1B2M2Y8AsgTpgAmY7PhCfg==", true},
+		{"sha256", models.File{Hash: emptySHA256, HashAlgorithm: "sha256"}, "SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", true},
+		{"unsupported algorithm", models.File{Hash: emptyMD5, HashAlgorithm: "xxh64"}, "", false},
+		{"invalid hash", models.File{Hash: "not-hex", HashAlgorithm: "md5"}, "", false},
+		{"missing hash", models.File{HashAlgorithm: "md5"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ContentDigest(&tt.file)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ContentDigest() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestContentMD5(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   models.File
+		want   string
+		wantOK bool
+	}{
+		{"md5", models.File{Hash: emptyMD5, HashAlgorithm: "md5"}, "1B2M2Y8AsgTpgAmY7PhCfg==", true},
+		{"default algorithm", models.File{Hash: emptyMD5}, "1B2M2Y8AsgTpgAmY7PhCfg==", true},
+		{"sha256", models.File{Hash: emptySHA256, HashAlgorithm: "sha256"}, "", false},
+		{"wrong length", models.File{Hash: "abcd", HashAlgorithm: "md5"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ContentMD5(&tt.file)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ContentMD5() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMetadataETag(t *testing.T) {
+	var file models.File
+	file.ID = uuid.MustParse("01563e3a-b5d3-d676-4c61-efb99302bd5b")
+	file.UpdatedAt = time.Unix(1700000000, 0)
+	etag := MetadataETag(&file, "")
+
+	if !strings.HasPrefix(etag, `W/"`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("MetadataETag() = %s, want a weak entity tag", etag)
+	}
+	if again := MetadataETag(&file, ""); again != etag {
+		t.Errorf("MetadataETag() is not stable: %s then %s", etag, again)
+	}
+
+	changes := map[string]func(f *models.File) string{
+		"variant": func(f *models.File) string { return MetadataETag(f, "include=urls") },
+		"update": func(f *models.File) string {
+			updated := *f
+			updated.UpdatedAt = f.UpdatedAt.Add(time.Nanosecond)
+			return MetadataETag(&updated, "")
+		},
+		"tags": func(f *models.File) string {
+			tagged := *f
+			tagged.Tags = []string{"invoice"}
+			return MetadataETag(&tagged, "")
+		},
+	}
+	for name, change := range changes {
+		if changed := change(&file); changed == etag {
+			t.Errorf("MetadataETag() unchanged after a %s change", name)
+		}
+	}
+}
+
+func TestSearchResultsETag(t *testing.T) {
+	etag := SearchResultsETag("3|1700000000", "page=1")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("SearchResultsETag() = %s, want a weak entity tag", etag)
+	}
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		variant     string
+		same        bool
+	}{
+		{"same inputs", "3|1700000000", "page=1", true},
+		{"file added", "4|1700000000", "page=1", false},
+		{"file updated", "3|1700000001", "page=1", false},
+		{"tag added", "3|1700000000|1|1700000002", "page=1", false},
+		{"other page", "3|1700000000", "page=2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchResultsETag(tt.fingerprint, tt.variant); (got == etag) != tt.same {
+				t.Errorf("SearchResultsETag(%q, %q) = %s, same as base = %v, want %v", tt.fingerprint, tt.variant, got, got == etag, tt.same)
+			}
+		})
+	}
+}
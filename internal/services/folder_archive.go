@@ -0,0 +1,103 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// archiveBatchSize is the number of file records loaded at a time while writing an archive
+const archiveBatchSize = 100
+
+// archiveExcludedStatuses lists statuses whose content is never included in archives
+var archiveExcludedStatuses = []string{"quarantined", "infected", "corrupted"}
+
+// WriteFolderArchive writes a gzip-compressed tar of every file in the folder and its subfolders
+// to w. Entries are named after each file's folder and original name, and content is streamed
+// from the backend holding it, so nothing is buffered in full. Files outside their access window
+// are left out. An empty prefix archives all files.
+func (s *FileService) WriteFolderArchive(w io.Writer, prefix string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	query := database.DB.Model(&models.File{}).
+		Where("status NOT IN ?", archiveExcludedStatuses).
+		Where("accessible_from IS NULL OR accessible_from <= ?", now).
+		Where("accessible_until IS NULL OR accessible_until > ?", now)
+	if prefix != "" {
+		query = query.Where("folder = ? OR starts_with(folder, ?)", prefix, prefix+"/")
+	}
+
+	seen := make(map[string]bool)
+	var batch []models.File
+	result := query.FindInBatches(&batch, archiveBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			name := archiveEntryName(&batch[i], seen)
+			if err := s.addFileToTar(tw, &batch[i], name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// archiveEntryName builds a unique entry name for a file; names already used in the archive
+// are disambiguated with the file ID. Names are kept relative and free of ".." so that no entry
+// extracts outside the archive's directory.
+func archiveEntryName(file *models.File, seen map[string]bool) string {
+	baseName := strings.NewReplacer("/", "_", "\\", "_").Replace(file.OriginalName)
+	if baseName == "" || baseName == "." || baseName == ".." {
+		baseName = file.ID.String()
+	}
+	// Cleaning against the root resolves any ".." in the folder without leaving it
+	folder := strings.TrimPrefix(path.Clean("/"+file.Folder), "/")
+
+	name := path.Join(folder, baseName)
+	if seen[name] {
+		name = path.Join(folder, file.ID.String()+"-"+baseName)
+	}
+	seen[name] = true
+	return name
+}
+
+// addFileToTar writes a single file's content as a tar entry
+func (s *FileService) addFileToTar(tw *tar.Writer, file *models.File, name string) error {
+	src, err := s.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer src.Close()
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    file.FileSize,
+		ModTime: file.UpdatedAt,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(tw, src, file.FileSize); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
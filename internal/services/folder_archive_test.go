@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestArchiveEntryName(t *testing.T) {
+	id := uuid.MustParse("01563e3a-b5d3-d676-4c61-efb99302bd5b")
+
+	tests := []struct {
+		name         string
+		folder       string
+		originalName string
+		want         string
+	}{
+		{"root file", "", "report.pdf", "report.pdf"},
+		{"folder file", "docs/2024", "report.pdf", "docs/2024/report.pdf"},
+		{"slash in name", "docs", "a/b.txt", "docs/a_b.txt"},
+		{"backslash in name", "docs", `..\..\evil.txt`, "docs/.._.._evil.txt"},
+		{"parent name", "docs", "..", "docs/" + id.String()},
+		{"parent traversal in name", "", "../../etc/passwd", ".._.._etc_passwd"},
+		{"parent traversal in folder", "../../etc", "passwd", "etc/passwd"},
+		{"absolute folder", "/etc", "passwd", "etc/passwd"},
+		{"empty name", "docs", "", "docs/" + id.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &models.File{Folder: tt.folder, OriginalName: tt.originalName}
+			file.ID = id
+			if got := archiveEntryName(file, map[string]bool{}); got != tt.want {
+				t.Errorf("archiveEntryName(%q, %q) = %q, want %q", tt.folder, tt.originalName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveEntryNameDuplicate(t *testing.T) {
+	seen := map[string]bool{}
+	first := &models.File{Folder: "docs", OriginalName: "report.pdf"}
+	first.ID = uuid.MustParse("01563e3a-b5d3-d676-4c61-efb99302bd5b")
+	second := &models.File{Folder: "docs", OriginalName: "report.pdf"}
+	second.ID = uuid.MustParse("0f4d2b7c-3c1e-4a55-9b0e-6f1c2d3e4a5b")
+
+	if got := archiveEntryName(first, seen); got != "docs/report.pdf" {
+		t.Errorf("first entry = %q, want docs/report.pdf", got)
+	}
+	if got, want := archiveEntryName(second, seen), "docs/"+second.ID.String()+"-report.pdf"; got != want {
+		t.Errorf("second entry = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"gorm.io/gorm/clause"
+)
+
+// FolderService manages virtual folders and validates folder paths
+type FolderService struct {
+	config config.FolderConfig
+}
+
+// NewFolderService creates a new folder service instance
+func NewFolderService() *FolderService {
+	return &FolderService{
+		config: config.GetConfig().Storage.Folders,
+	}
+}
+
+// AutoCreate reports whether uploads may create missing folders
+func (s *FolderService) AutoCreate() bool {
+	return s.config.AutoCreate
+}
+
+// IsUniqueNamesEnabled reports whether file names must be unique within a folder
+func (s *FolderService) IsUniqueNamesEnabled() bool {
+	return s.config.UniqueNames
+}
+
+// FindTakenNames returns which of the names are already used by files in the folder,
+// ignoring the file with excludeID (uuid.Nil to check against all files)
+func (s *FolderService) FindTakenNames(folder string, names []string, excludeID uuid.UUID) ([]string, error) {
+	var taken []string
+	query := database.DB.Model(&models.File{}).Where("folder = ? AND original_name IN ?", folder, names)
+	if excludeID != uuid.Nil {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Distinct("original_name").Pluck("original_name", &taken).Error
+	return taken, err
+}
+
+// getMaxDepth returns the maximum number of folder levels
+func (s *FolderService) getMaxDepth() int {
+	if s.config.MaxDepth <= 0 {
+		return 10
+	}
+	return s.config.MaxDepth
+}
+
+// getMaxNameLength returns the maximum length of a single folder name
+func (s *FolderService) getMaxNameLength() int {
+	if s.config.MaxNameLength <= 0 {
+		return 255
+	}
+	return s.config.MaxNameLength
+}
+
+// NormalizePath validates a folder path and returns it without leading or trailing slashes.
+// An empty path denotes the root folder.
+func (s *FolderService) NormalizePath(folderPath string) (string, error) {
+	folderPath = strings.Trim(strings.TrimSpace(folderPath), "/")
+	if folderPath == "" {
+		return "", nil
+	}
+
+	names := strings.Split(folderPath, "/")
+	if len(names) > s.getMaxDepth() {
+		return "", errors.BadRequestError("FOLDER_TOO_DEEP", fmt.Sprintf("Folder depth exceeds the maximum of %d", s.getMaxDepth()))
+	}
+
+	for _, name := range names {
+		if err := s.validateName(name); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(names, "/"), nil
+}
+
+// validateName checks a single folder name
+func (s *FolderService) validateName(name string) error {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." {
+		return errors.BadRequestError("INVALID_FOLDER_NAME", fmt.Sprintf("Invalid folder name: %q", name))
+	}
+	if len(name) > s.getMaxNameLength() {
+		return errors.BadRequestError("INVALID_FOLDER_NAME", fmt.Sprintf("Folder name exceeds %d characters", s.getMaxNameLength()))
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || r == '\\' {
+			return errors.BadRequestError("INVALID_FOLDER_NAME", fmt.Sprintf("Folder name contains invalid characters: %q", name))
+		}
+	}
+	return nil
+}
+
+// Exists reports whether a normalized folder path exists; the root folder always exists
+func (s *FolderService) Exists(folderPath string) (bool, error) {
+	if folderPath == "" {
+		return true, nil
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Folder{}).Where("path = ?", folderPath).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateFolder creates a normalized folder path along with any missing parent folders
+func (s *FolderService) CreateFolder(folderPath string) (*models.Folder, error) {
+	if folderPath == "" {
+		return nil, errors.BadRequestError("INVALID_FOLDER_NAME", "Folder path is required")
+	}
+
+	var folder models.Folder
+	parentPath := ""
+	for _, name := range strings.Split(folderPath, "/") {
+		currentPath := path.Join(parentPath, name)
+		folder = models.Folder{
+			Path:       currentPath,
+			Name:       name,
+			ParentPath: parentPath,
+		}
+
+		// Concurrent uploads may create the same folder; ignore the duplicate and load the existing row
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&folder).Error; err != nil {
+			return nil, err
+		}
+		if err := database.DB.Where("path = ?", currentPath).First(&folder).Error; err != nil {
+			return nil, err
+		}
+
+		parentPath = currentPath
+	}
+
+	return &folder, nil
+}
+
+// ListFolders returns the direct subfolders of a normalized folder path
+func (s *FolderService) ListFolders(parentPath string) ([]models.Folder, error) {
+	var folders []models.Folder
+	if err := database.DB.Where("parent_path = ?", parentPath).Order("name ASC").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
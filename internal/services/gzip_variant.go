@@ -0,0 +1,121 @@
+package services
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"storage-api/internal/models"
+	"storage-api/internal/utils"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// compressibleTypes lists non-text MIME types that benefit from gzip
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/wasm":       true,
+	"image/svg+xml":          true,
+	"image/bmp":              true,
+	"application/x-tar":      true,
+}
+
+// GzipVariantPath returns the path of a file's precompressed gzip variant
+func GzipVariantPath(file *models.File) string {
+	return file.FilePath + ".gz"
+}
+
+// GzipETag derives the entity tag of the gzip variant from the identity ETag
+func GzipETag(etag string) string {
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// ShouldPrecompress reports whether a gzip variant should be generated for a file
+func (s *FileService) ShouldPrecompress(file *models.File) bool {
+	gzipConfig := s.config.Download.GzipVariants
+	if !gzipConfig.Enabled {
+		return false
+	}
+
+	if gzipConfig.MinSize != "" {
+		minSize, err := utils.ParseSizeString(gzipConfig.MinSize)
+		if err == nil && file.FileSize < minSize {
+			return false
+		}
+	}
+
+	mimeType := strings.ToLower(strings.TrimSpace(strings.Split(file.MimeType, ";")[0]))
+	return strings.HasPrefix(mimeType, "text/") || compressibleTypes[mimeType]
+}
+
+// ShouldServeGzip reports whether the gzip variant should be sent to a client with the given Accept-Encoding
+func (s *FileService) ShouldServeGzip(file *models.File, acceptEncoding string) bool {
+	return s.config.Download.GzipVariants.Enabled && file.HasGzipVariant && utils.AcceptsEncoding(acceptEncoding, "gzip")
+}
+
+// CreateGzipVariant writes a gzip-compressed copy of a file next to it.
+// It returns false without keeping a variant when compression doesn't save the configured minimum,
+// so incompressible content is only ever served in its original form.
+func (s *FileService) CreateGzipVariant(file *models.File) (bool, error) {
+	src, err := s.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return false, errors.InternalError("FILE_OPEN_ERROR", fmt.Sprintf("Failed to open file: %v", err))
+	}
+	defer src.Close()
+
+	// Write to a temporary file first so readers never see a partial variant
+	variantPath := GzipVariantPath(file)
+	tmpPath := variantPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return false, errors.InternalError("FILE_CREATION_ERROR", fmt.Sprintf("Failed to create gzip variant: %v", err))
+	}
+
+	writer := gzip.NewWriter(dst)
+	_, err = io.Copy(writer, src)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return false, errors.InternalError("GZIP_ERROR", fmt.Sprintf("Failed to compress file: %v", err))
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil || !s.gzipSavesEnough(file.FileSize, info.Size()) {
+		os.Remove(tmpPath)
+		return false, nil
+	}
+
+	if err := os.Rename(tmpPath, variantPath); err != nil {
+		os.Remove(tmpPath)
+		return false, errors.InternalError("GZIP_ERROR", fmt.Sprintf("Failed to store gzip variant: %v", err))
+	}
+
+	return true, nil
+}
+
+// gzipSavesEnough reports whether a compressed size is small enough, relative to the original, to be
+// worth keeping. The variant must always be strictly smaller.
+func (s *FileService) gzipSavesEnough(originalSize, compressedSize int64) bool {
+	if compressedSize >= originalSize {
+		return false
+	}
+	minSavings := min(max(int64(s.config.Download.GzipVariants.MinSavingsPercent), 0), 100)
+	return compressedSize <= originalSize*(100-minSavings)/100
+}
+
+// DeleteGzipVariant removes a file's gzip variant if one exists
+func (s *FileService) DeleteGzipVariant(file *models.File) error {
+	if err := os.Remove(GzipVariantPath(file)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
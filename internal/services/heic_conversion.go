@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// heicBrands lists ISO-BMFF major brands of HEVC-coded HEIC images. The generic HEIF brands
+// (mif1, msf1) are left out since AVIF and other HEIF images use them too.
+var heicBrands = map[string]bool{
+	"heic": true,
+	"heix": true,
+	"hevc": true,
+	"hevx": true,
+}
+
+// ImageConversion describes the outcome of converting a stored image to another format
+type ImageConversion struct {
+	FilePath         string
+	Size             int64
+	OriginalFilePath string
+}
+
+// IsHEIC reports whether the file at the given path is a HEIC/HEIF image based on its magic bytes
+func IsHEIC(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// The ftyp box starts at offset 4 and is followed by the major brand
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !bytes.Equal(header[4:8], []byte("ftyp")) {
+		return false, nil
+	}
+
+	return heicBrands[string(header[8:12])], nil
+}
+
+// shouldConvertHEIC reports whether the stored file must be transcoded to JPEG
+func (s *FileService) shouldConvertHEIC(filePath string) bool {
+	if !s.config.HEICConversion.Enabled {
+		return false
+	}
+
+	isHEIC, err := IsHEIC(filePath)
+	if err != nil {
+		return false
+	}
+
+	return isHEIC
+}
+
+// ConvertHEICToJPEG transcodes a stored HEIC image to JPEG using the configured converter.
+// The original is removed unless keepOriginal is set.
+func (s *FileService) ConvertHEICToJPEG(filePath string, keepOriginal bool) (*ImageConversion, error) {
+	outputPath := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".jpg"
+	if _, err := os.Stat(outputPath); err == nil {
+		return nil, errors.ConflictError("FILE_EXISTS", fmt.Sprintf("Converted file %s already exists", filepath.Base(outputPath)))
+	}
+
+	converter := s.config.HEICConversion.Converter
+	if converter == "" {
+		converter = "heif-convert"
+	}
+
+	quality := s.config.HEICConversion.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 90
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, converter, "-q", strconv.Itoa(quality), filePath, outputPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(outputPath)
+		return nil, errors.InternalError("HEIC_CONVERSION_ERROR", fmt.Sprintf("Failed to convert HEIC image: %v: %s", err, strings.TrimSpace(string(output))))
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, errors.InternalError("HEIC_CONVERSION_ERROR", fmt.Sprintf("Converted file not found: %v", err))
+	}
+
+	conversion := &ImageConversion{
+		FilePath: outputPath,
+		Size:     info.Size(),
+	}
+
+	if keepOriginal {
+		conversion.OriginalFilePath = filePath
+	} else if err := os.Remove(filePath); err != nil {
+		return nil, errors.InternalError("FILE_DELETE_ERROR", fmt.Sprintf("Failed to remove original HEIC file: %v", err))
+	}
+
+	return conversion, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsHEIC(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"heic", "\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic", true},
+		{"heix", "\x00\x00\x00\x18ftypheix\x00\x00\x00\x00mif1heix", true},
+		{"hevc sequence", "\x00\x00\x00\x18ftyphevc\x00\x00\x00\x00msf1hevc", true},
+		{"avif", "\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf", false},
+		{"generic heif", "\x00\x00\x00\x18ftypmif1\x00\x00\x00\x00mif1avif", false},
+		{"mp4", "\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2", false},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00", false},
+		{"short", "\x00\x00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "image")
+			if err := os.WriteFile(path, []byte(tt.header), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := IsHEIC(path)
+			if err != nil {
+				t.Fatalf("IsHEIC() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsHEIC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+)
+
+// IsHotlinkProtectionEnabled reports whether downloads are restricted to allowed referring sites
+func (s *FileService) IsHotlinkProtectionEnabled() bool {
+	return len(s.config.Download.HotlinkProtection.AllowedOrigins) > 0
+}
+
+// IsRefererAllowed reports whether a download request with the given Origin and Referer headers
+// comes from an allowed site. Origin is preferred because browsers send it even when the referrer
+// policy strips Referer; requests with neither header follow the empty-referer policy.
+func (s *FileService) IsRefererAllowed(origin, referer string) bool {
+	hotlinkConfig := s.config.Download.HotlinkProtection
+	if len(hotlinkConfig.AllowedOrigins) == 0 {
+		return true
+	}
+
+	// Sandboxed and privacy-sensitive contexts send the opaque origin "null"
+	source := origin
+	if source == "" || source == "null" {
+		source = referer
+	}
+	if source == "" {
+		return hotlinkConfig.AllowEmptyReferer
+	}
+
+	parsed, err := url.Parse(source)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, allowed := range hotlinkConfig.AllowedOrigins {
+		if originMatches(allowed, parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// originMatches reports whether a referring URL matches an allowlist entry. Entries are a host
+// ("example.com"), optionally with a scheme ("https://example.com"), a port, or a leading "*."
+// wildcard matching any subdomain. Entries without a port match any port.
+func originMatches(allowed string, source *url.URL) bool {
+	allowed = strings.ToLower(strings.TrimRight(strings.TrimSpace(allowed), "/"))
+	if scheme, host, ok := strings.Cut(allowed, "://"); ok {
+		if scheme != strings.ToLower(source.Scheme) {
+			return false
+		}
+		allowed = host
+	}
+
+	host := strings.ToLower(source.Hostname())
+	if strings.Contains(allowed, ":") {
+		host = strings.ToLower(source.Host)
+	}
+
+	if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == allowed
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"storage-api/internal/config"
+)
+
+func TestIsRefererAllowed(t *testing.T) {
+	allowed := []string{"example.com", "https://app.example.org", "*.cdn.example.net", "localhost:3000"}
+
+	tests := []struct {
+		name       string
+		allowEmpty bool
+		origin     string
+		referer    string
+		want       bool
+	}{
+		{"allowed host", false, "", "https://example.com/page", true},
+		{"allowed host any scheme", false, "", "http://example.com/", true},
+		{"host case", false, "", "https://EXAMPLE.com/", true},
+		{"allowed origin", false, "https://example.com", "", true},
+		{"origin preferred over referer", false, "https://evil.test", "https://example.com/", false},
+		{"null origin falls back to referer", false, "null", "https://example.com/", true},
+		{"other site", false, "", "https://evil.test/", false},
+		{"subdomain of exact host", false, "", "https://www.example.com/", false},
+		{"suffix lookalike", false, "", "https://notexample.com/", false},
+		{"scheme entry", false, "", "https://app.example.org/", true},
+		{"scheme entry wrong scheme", false, "", "http://app.example.org/", false},
+		{"wildcard subdomain", false, "", "https://img.cdn.example.net/", true},
+		{"wildcard apex", false, "", "https://cdn.example.net/", false},
+		{"port entry", false, "", "http://localhost:3000/", true},
+		{"port entry other port", false, "", "http://localhost:8080/", false},
+		{"malformed referer", false, "", "not a url", false},
+		{"empty refused", false, "", "", false},
+		{"empty allowed", true, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &FileService{config: config.StorageConfig{Download: config.DownloadConfig{
+				HotlinkProtection: config.HotlinkConfig{AllowedOrigins: allowed, AllowEmptyReferer: tt.allowEmpty},
+			}}}
+			if got := s.IsRefererAllowed(tt.origin, tt.referer); got != tt.want {
+				t.Errorf("IsRefererAllowed(%q, %q) = %v, want %v", tt.origin, tt.referer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRefererAllowedDisabled(t *testing.T) {
+	s := &FileService{}
+	if s.IsHotlinkProtectionEnabled() {
+		t.Fatal("IsHotlinkProtectionEnabled() = true without allowed origins")
+	}
+	if !s.IsRefererAllowed("", "https://evil.test/") {
+		t.Error("IsRefererAllowed() refused a request with protection disabled")
+	}
+}
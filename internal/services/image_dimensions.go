@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"storage-api/internal/config"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// validateImageDimensions rejects images outside the resolution limits of the rule they match.
+// Only the image header is decoded. Files in formats without a registered decoder (e.g. SVG or
+// HEIC) aren't measured and pass, since their size can't be read cheaply.
+func (s *FileService) validateImageDimensions(file *UploadSource, rule *config.ValidationRule) error {
+	if rule == nil || !rule.HasImageDimensionLimits() {
+		return nil
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for image dimension check")
+	}
+	defer src.Close()
+
+	imageConfig, format, err := image.DecodeConfig(src)
+	if err == image.ErrFormat {
+		return nil
+	}
+	if err != nil {
+		return errors.BadRequestError("INVALID_IMAGE", fmt.Sprintf("Failed to read %s image header: %v", format, err))
+	}
+
+	width, height := imageConfig.Width, imageConfig.Height
+	if width < rule.MinWidth || height < rule.MinHeight {
+		return errors.BadRequestError("IMAGE_TOO_SMALL", fmt.Sprintf("Image is %dx%d pixels, smaller than the minimum of %s",
+			width, height, formatDimensionLimit(rule.MinWidth, rule.MinHeight)))
+	}
+	if (rule.MaxWidth > 0 && width > rule.MaxWidth) || (rule.MaxHeight > 0 && height > rule.MaxHeight) {
+		return errors.BadRequestError("IMAGE_TOO_LARGE", fmt.Sprintf("Image is %dx%d pixels, exceeding the maximum of %s",
+			width, height, formatDimensionLimit(rule.MaxWidth, rule.MaxHeight)))
+	}
+	return nil
+}
+
+// formatDimensionLimit describes a resolution limit, where 0 leaves a dimension unlimited
+func formatDimensionLimit(width, height int) string {
+	switch {
+	case width > 0 && height > 0:
+		return fmt.Sprintf("%dx%d", width, height)
+	case width > 0:
+		return fmt.Sprintf("%d pixels wide", width)
+	default:
+		return fmt.Sprintf("%d pixels high", height)
+	}
+}
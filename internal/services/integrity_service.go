@@ -0,0 +1,180 @@
+package services
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+var (
+	integrityMutex  sync.Mutex
+	integrityStatus IntegrityStatus
+)
+
+// IntegrityStatus describes the current or last integrity verification run
+type IntegrityStatus struct {
+	Running     bool       `json:"running"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Checked     int64      `json:"checked"`
+	Corrupted   int64      `json:"corrupted"`
+	Missing     int64      `json:"missing"`
+	Errors      int64      `json:"errors"`
+}
+
+// IntegrityService verifies stored content against recorded hashes
+type IntegrityService struct {
+	fileService *FileService
+	config      config.IntegrityConfig
+}
+
+// NewIntegrityService creates a new integrity service instance
+func NewIntegrityService() *IntegrityService {
+	return &IntegrityService{
+		fileService: NewFileService(),
+		config:      config.GetConfig().Storage.Integrity,
+	}
+}
+
+// Start launches a verification run in the background unless one is already running
+func (s *IntegrityService) Start() error {
+	integrityMutex.Lock()
+	defer integrityMutex.Unlock()
+
+	if integrityStatus.Running {
+		return errors.ConflictError("INTEGRITY_RUNNING", "Integrity verification is already running")
+	}
+
+	now := time.Now()
+	integrityStatus = IntegrityStatus{Running: true, StartedAt: &now}
+
+	go func() {
+		err := s.run()
+
+		integrityMutex.Lock()
+		completedAt := time.Now()
+		integrityStatus.Running = false
+		integrityStatus.CompletedAt = &completedAt
+		integrityMutex.Unlock()
+
+		if err != nil {
+			log.Printf("Integrity verification stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// StartSchedule runs verification periodically when an interval is configured
+func (s *IntegrityService) StartSchedule() {
+	if s.config.IntervalHours <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.config.IntervalHours) * time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.Start(); err != nil {
+				log.Printf("Skipping scheduled integrity verification: %v", err)
+			}
+		}
+	}()
+}
+
+// GetStatus returns the state of the current or last run
+func (s *IntegrityService) GetStatus() IntegrityStatus {
+	integrityMutex.Lock()
+	defer integrityMutex.Unlock()
+	return integrityStatus
+}
+
+// run verifies every file in keyset-ordered batches
+func (s *IntegrityService) run() error {
+	batchSize := s.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	var throttle time.Duration
+	if s.config.MaxFilesPerSecond > 0 {
+		throttle = time.Second / time.Duration(s.config.MaxFilesPerSecond)
+	}
+
+	lastID := ""
+	for {
+		// Files still being scanned or already flagged are skipped
+		query := database.DB.Where("status NOT IN ?", []string{"quarantined", "infected", "corrupted"}).Order("id ASC").Limit(batchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+
+		var files []models.File
+		if err := query.Find(&files).Error; err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			status := s.GetStatus()
+			log.Printf("Integrity verification completed: %d checked, %d corrupted, %d missing, %d errors", status.Checked, status.Corrupted, status.Missing, status.Errors)
+			return nil
+		}
+
+		for _, file := range files {
+			s.verify(&file)
+			lastID = file.ID.String()
+
+			if throttle > 0 {
+				time.Sleep(throttle)
+			}
+		}
+	}
+}
+
+// verify recomputes a file's hash and flags it when the content no longer matches
+func (s *IntegrityService) verify(file *models.File) {
+	var missing, corrupted, failed bool
+
+	if _, err := s.fileService.GetBackend(file.Backend).Stat(file.FilePath); os.IsNotExist(err) {
+		missing = true
+	} else if hash, err := s.fileService.CalculateFileHashWithAlgorithm(file.Backend, file.FilePath, file.HashAlgorithm); err != nil {
+		failed = true
+		log.Printf("Warning: Failed to verify file %s: %v", file.ID, err)
+	} else if hash != file.Hash {
+		corrupted = true
+	}
+
+	integrityMutex.Lock()
+	integrityStatus.Checked++
+	switch {
+	case missing:
+		integrityStatus.Missing++
+	case corrupted:
+		integrityStatus.Corrupted++
+	case failed:
+		integrityStatus.Errors++
+	}
+	integrityMutex.Unlock()
+
+	if !missing && !corrupted {
+		return
+	}
+
+	log.Printf("Warning: File %s (%s) failed integrity verification (missing: %t)", file.ID, file.OriginalName, missing)
+	if !s.config.AutoMarkCorrupted {
+		return
+	}
+
+	if err := database.WithRetry(func() error {
+		return database.DB.Model(file).Update("status", "corrupted").Error
+	}); err != nil {
+		log.Printf("Warning: Failed to mark file %s as corrupted: %v", file.ID, err)
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// newTestLinkService returns a link service signing with a fixed key
+func newTestLinkService(signing bool, key string) *LinkService {
+	return &LinkService{
+		config:     config.LinkConfig{Signing: signing, TTLSeconds: 60},
+		signingKey: []byte(key),
+	}
+}
+
+// testLinkFile returns an image file, which has download and thumbnail links
+func testLinkFile() *models.File {
+	file := &models.File{Extension: "jpg"}
+	file.ID = uuid.MustParse("01563e3a-b5d3-d676-4c61-efb99302bd5b")
+	return file
+}
+
+// parseLink splits a link into its path and query
+func parseLink(t *testing.T, link string) (string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid link %q: %v", link, err)
+	}
+	return parsed.Path, parsed.Query()
+}
+
+func TestBuildLinksSigned(t *testing.T) {
+	s := newTestLinkService(true, "test-signing-key")
+	links := s.BuildLinks(testLinkFile(), &ThumbnailService{}, &PreviewService{})
+
+	downloadPath, download := parseLink(t, links.Download)
+	thumbnailPath, thumbnail := parseLink(t, links.Thumbnail)
+	if downloadPath != "/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b" {
+		t.Errorf("download path = %s", downloadPath)
+	}
+	if download.Get("download") != "1" {
+		t.Errorf("download link %q lacks download=1", links.Download)
+	}
+	if links.Preview != "" {
+		t.Errorf("preview link %q for a file without previews", links.Preview)
+	}
+
+	expires, err := strconv.ParseInt(download.Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatalf("download link %q has no expiry: %v", links.Download, err)
+	}
+	if ttl := time.Until(time.Unix(expires, 0)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("download link expires in %s, want within the 60s TTL", ttl)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		expires   string
+		signature string
+		wantErr   bool
+	}{
+		{"download", downloadPath, download.Get("expires"), download.Get("signature"), false},
+		{"thumbnail", thumbnailPath, thumbnail.Get("expires"), thumbnail.Get("signature"), false},
+		{"signature for another path", downloadPath, thumbnail.Get("expires"), thumbnail.Get("signature"), true},
+		{"extended expiry", downloadPath, strconv.FormatInt(expires+3600, 10), download.Get("signature"), true},
+		{"altered signature", downloadPath, download.Get("expires"), strings.Repeat("0", 64), true},
+		{"missing signature", downloadPath, download.Get("expires"), "", true},
+		{"missing expiry", downloadPath, "", download.Get("signature"), true},
+		{"malformed expiry", downloadPath, "soon", download.Get("signature"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.VerifySignature(tt.path, tt.expires, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureExpired(t *testing.T) {
+	s := newTestLinkService(true, "test-signing-key")
+	path := "/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b"
+	expires := time.Now().Add(-time.Second).Unix()
+
+	err := s.VerifySignature(path, strconv.FormatInt(expires, 10), s.sign(path, expires))
+	if err == nil {
+		t.Error("VerifySignature() accepted an expired signature")
+	}
+}
+
+func TestVerifySignatureOtherKey(t *testing.T) {
+	signer := newTestLinkService(true, "test-signing-key")
+	verifier := newTestLinkService(true, "another-key")
+	_, query := parseLink(t, signer.BuildLinks(testLinkFile(), &ThumbnailService{}, &PreviewService{}).Download)
+
+	err := verifier.VerifySignature("/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b", query.Get("expires"), query.Get("signature"))
+	if err == nil {
+		t.Error("VerifySignature() accepted a signature made with another key")
+	}
+}
+
+func TestBuildLinksUnsigned(t *testing.T) {
+	tests := []struct {
+		name    string
+		signing bool
+		key     string
+	}{
+		{"signing disabled", false, "test-signing-key"},
+		{"signing without a key", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestLinkService(tt.signing, tt.key)
+			if s.IsSigningEnabled() {
+				t.Fatal("IsSigningEnabled() = true, want false")
+			}
+			links := s.BuildLinks(testLinkFile(), &ThumbnailService{}, &PreviewService{})
+			if want := "/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b?download=1"; links.Download != want {
+				t.Errorf("download link = %s, want %s", links.Download, want)
+			}
+		})
+	}
+}
+
+func TestBuildLinksBaseURL(t *testing.T) {
+	s := newTestLinkService(false, "")
+	s.config.BaseURL = "https://files.example.com/"
+
+	links := s.BuildLinks(testLinkFile(), &ThumbnailService{}, &PreviewService{})
+	if want := "https://files.example.com/api/v1/files/01563e3a-b5d3-d676-4c61-efb99302bd5b/thumbnail"; links.Thumbnail != want {
+		t.Errorf("thumbnail link = %s, want %s", links.Thumbnail, want)
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DuplicateStoredName describes a stored name shared by more than one file record
+type DuplicateStoredName struct {
+	StoredName string   `json:"storedName"`
+	Count      int64    `json:"count"`
+	FileIDs    []string `json:"fileIds"`
+}
+
+// StoredNameRepair describes a file that was given a new stored name
+type StoredNameRepair struct {
+	FileID        string `json:"fileId"`
+	OldStoredName string `json:"oldStoredName"`
+	NewStoredName string `json:"newStoredName"`
+	Error         string `json:"error,omitempty"`
+}
+
+// StoredNameReport is the result of a duplicate stored name scan
+type StoredNameReport struct {
+	Duplicates   []DuplicateStoredName `json:"duplicates"`
+	Repairs      []StoredNameRepair    `json:"repairs,omitempty"`
+	IndexRebuilt bool                  `json:"indexRebuilt"`
+}
+
+// MaintenanceService performs administrative consistency checks and repairs
+type MaintenanceService struct{}
+
+// NewMaintenanceService creates a new maintenance service instance
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{}
+}
+
+// FindDuplicateStoredNames lists stored names used by more than one file, oldest file first
+func (s *MaintenanceService) FindDuplicateStoredNames() ([]DuplicateStoredName, error) {
+	var groups []struct {
+		StoredName string
+		Count      int64
+	}
+	if err := database.DB.Model(&models.File{}).
+		Select("stored_name, COUNT(*) AS count").
+		Group("stored_name").
+		Having("COUNT(*) > 1").
+		Order("stored_name ASC").
+		Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]DuplicateStoredName, 0, len(groups))
+	for _, group := range groups {
+		var files []models.File
+		if err := database.DB.Where("stored_name = ?", group.StoredName).Order("created_at ASC").Order("id ASC").Find(&files).Error; err != nil {
+			return nil, err
+		}
+
+		duplicate := DuplicateStoredName{StoredName: group.StoredName, Count: group.Count}
+		for _, file := range files {
+			duplicate.FileIDs = append(duplicate.FileIDs, file.ID.String())
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	return duplicates, nil
+}
+
+// CheckStoredNames reports duplicate stored names. With repair set, every duplicate except the
+// oldest file is renamed (on disk and in the database) to a fresh UUID-based name, and the
+// unique index is recreated once no duplicates remain.
+func (s *MaintenanceService) CheckStoredNames(repair bool) (*StoredNameReport, error) {
+	duplicates, err := s.FindDuplicateStoredNames()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StoredNameReport{Duplicates: duplicates}
+	if !repair {
+		return report, nil
+	}
+
+	failed := false
+	for _, duplicate := range duplicates {
+		// Keep the oldest file's name; rename the rest
+		for _, fileID := range duplicate.FileIDs[1:] {
+			result := s.renameStoredFile(fileID)
+			if result.Error != "" {
+				failed = true
+			}
+			report.Repairs = append(report.Repairs, result)
+		}
+	}
+
+	if failed {
+		return report, nil
+	}
+
+	// Recreate the unique index in case it could not be built while duplicates existed
+	if err := database.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_files_stored_name ON files (stored_name)").Error; err != nil {
+		return nil, err
+	}
+	report.IndexRebuilt = true
+
+	return report, nil
+}
+
+// renameStoredFile gives a file a new unique stored name, moving its content accordingly
+func (s *MaintenanceService) renameStoredFile(fileID string) StoredNameRepair {
+	result := StoredNameRepair{FileID: fileID}
+
+	var file models.File
+	if err := database.DB.Where("id = ?", fileID).First(&file).Error; err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OldStoredName = file.StoredName
+
+	newStoredName := uuid.NewString() + filepath.Ext(file.StoredName)
+	newFilePath := filepath.Join(filepath.Dir(file.FilePath), newStoredName)
+
+	if err := os.Rename(file.FilePath, newFilePath); err != nil && !os.IsNotExist(err) {
+		result.Error = fmt.Sprintf("failed to rename file on disk: %v", err)
+		return result
+	}
+
+	previousPath := file.FilePath
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&file).Updates(map[string]interface{}{
+			"stored_name": newStoredName,
+			"file_path":   newFilePath,
+		}).Error; err != nil {
+			return err
+		}
+		file.StoredName = newStoredName
+		file.FilePath = newFilePath
+		return recordFileMove(tx, &file, file.Backend, previousPath, PathReasonStoredNameFix)
+	})
+	if err != nil {
+		// Move the content back so the record still points at it
+		if renameErr := os.Rename(newFilePath, previousPath); renameErr != nil && !os.IsNotExist(renameErr) {
+			log.Printf("Warning: Failed to restore %s after stored name repair failed: %v", previousPath, renameErr)
+		}
+		result.Error = fmt.Sprintf("failed to update file record: %v", err)
+		return result
+	}
+
+	result.NewStoredName = newStoredName
+	return result
+}
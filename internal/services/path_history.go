@@ -0,0 +1,57 @@
+package services
+
+import (
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Path history reasons
+const (
+	PathReasonUploaded      = "uploaded"
+	PathReasonRecorded      = "recorded"
+	PathReasonStoredNameFix = "stored_name_repair"
+)
+
+// RecordFilePath appends a file's current location to its path history
+func RecordFilePath(db *gorm.DB, file *models.File, reason string) error {
+	return db.Create(&models.FilePathHistory{
+		FileID:   file.ID,
+		Backend:  file.Backend,
+		FilePath: file.FilePath,
+		Reason:   reason,
+	}).Error
+}
+
+// recordFileMove appends a file's new location to its path history. Files stored before path
+// history existed have no entries yet, so their previous location is recorded first.
+func recordFileMove(db *gorm.DB, file *models.File, previousBackend, previousPath, reason string) error {
+	var count int64
+	if err := db.Model(&models.FilePathHistory{}).Where("file_id = ?", file.ID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		previous := models.FilePathHistory{
+			FileID:   file.ID,
+			Backend:  previousBackend,
+			FilePath: previousPath,
+			Reason:   PathReasonRecorded,
+		}
+		if err := db.Create(&previous).Error; err != nil {
+			return err
+		}
+	}
+
+	return RecordFilePath(db, file, reason)
+}
+
+// GetFilePathHistory returns the locations a file has been stored at, oldest first
+func GetFilePathHistory(file *models.File) ([]models.FilePathHistory, error) {
+	var history []models.FilePathHistory
+	if err := database.DB.Where("file_id = ?", file.ID).Order("created_at").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
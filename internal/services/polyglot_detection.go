@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"storage-api/internal/utils"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+const (
+	// polyglotScanSize is how much of the start of a file is scanned for foreign content
+	polyglotScanSize = 4 * 1024 * 1024
+	// polyglotTailSize is how much of the end of a file is checked for data after the end marker
+	polyglotTailSize = 64 * 1024
+)
+
+// polyglotMarkers are script and markup fragments that have no business inside binary images,
+// media or PDFs, but let a browser or interpreter treat the file as another type. They are long
+// enough not to turn up by chance in compressed data.
+var polyglotMarkers = [][]byte{
+	[]byte("<script"),
+	[]byte("<?php"),
+	[]byte("<iframe"),
+	[]byte("<!doctype html"),
+	[]byte("javascript:"),
+}
+
+// embeddedArchiveSignatures are archive headers that make a file readable as an archive as well
+var embeddedArchiveSignatures = [][]byte{
+	[]byte("Rar!\x1a\x07"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+}
+
+// zipEndSignature marks the end of central directory record that ZIP readers look for at the end of a file
+var zipEndSignature = []byte("PK\x05\x06")
+
+// validatePolyglot rejects binary files that also look like script, markup or an archive. The
+// checks are heuristic: they catch common polyglot constructions (e.g. a GIF that is also valid
+// JavaScript, or an image with an appended ZIP) but can't prove a file has only one reading.
+func (s *FileService) validatePolyglot(file *UploadSource) error {
+	if !s.config.Validation.RejectPolyglots {
+		return nil
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return errors.InternalError("FILE_OPEN_ERROR", "Failed to open file for polyglot detection")
+	}
+	defer src.Close()
+
+	head, err := io.ReadAll(io.LimitReader(src, polyglotScanSize))
+	if err != nil {
+		return errors.InternalError("FILE_READ_ERROR", "Failed to read file for polyglot detection")
+	}
+
+	// The end of the file is needed to check for appended data; read it separately when it
+	// lies beyond the scanned start and the source supports random access
+	tail := head[max(len(head)-polyglotTailSize, 0):]
+	if int64(len(head)) < file.Size {
+		tail = nil
+		if readerAt, ok := src.(io.ReaderAt); ok {
+			size := min(int64(polyglotTailSize), file.Size)
+			buffer := make([]byte, size)
+			if n, err := readerAt.ReadAt(buffer, file.Size-size); err == nil || err == io.EOF {
+				tail = buffer[:n]
+			}
+		}
+	}
+
+	if reason := detectPolyglot(utils.DetectContentType(head), head, tail); reason != "" {
+		return errors.BadRequestError("POLYGLOT_DETECTED", "File appears valid as more than one type: "+reason)
+	}
+	return nil
+}
+
+// detectPolyglot returns why content of the detected type looks like a polyglot, or "" if it doesn't.
+// Text and archive types legitimately contain markup and archive headers, so only binary images,
+// media and PDFs are checked.
+func detectPolyglot(detectedType string, head, tail []byte) string {
+	if !strings.HasPrefix(detectedType, "image/") && !strings.HasPrefix(detectedType, "audio/") &&
+		!strings.HasPrefix(detectedType, "video/") && detectedType != "application/pdf" {
+		return ""
+	}
+	// SVG is markup by definition
+	if detectedType == "image/svg+xml" {
+		return ""
+	}
+
+	// A GIF whose logical screen size bytes open a comment is the classic GIF/JavaScript polyglot
+	if detectedType == "image/gif" && len(head) >= 8 && bytes.Equal(head[6:8], []byte("/*")) {
+		return "GIF header opens a script comment"
+	}
+
+	lower := bytes.ToLower(head)
+	for _, marker := range polyglotMarkers {
+		if bytes.Contains(lower, marker) {
+			return "embedded " + string(marker) + " content"
+		}
+	}
+
+	for _, signature := range embeddedArchiveSignatures {
+		if len(head) > 1 && bytes.Contains(head[1:], signature) {
+			return "embedded archive"
+		}
+	}
+	if bytes.Contains(tail, zipEndSignature) {
+		return "embedded ZIP archive"
+	}
+
+	if tail != nil && hasTrailingData(detectedType, tail) {
+		return "data after the end of the image"
+	}
+	return ""
+}
+
+// hasTrailingData reports whether an image continues past its end marker. Trailing NUL padding,
+// which some encoders add, is ignored.
+func hasTrailingData(detectedType string, tail []byte) bool {
+	tail = bytes.TrimRight(tail, "\x00")
+
+	switch detectedType {
+	case "image/png":
+		// The IEND chunk type is followed only by its 4-byte CRC
+		end := bytes.LastIndex(tail, []byte("IEND"))
+		return end >= 0 && len(tail)-(end+8) > 0
+	case "image/jpeg":
+		end := bytes.LastIndex(tail, []byte{0xFF, 0xD9})
+		return end >= 0 && len(tail)-(end+2) > 0
+	case "image/gif":
+		return len(tail) > 0 && tail[len(tail)-1] != 0x3B
+	}
+	return false
+}
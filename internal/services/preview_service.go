@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// PreviewService renders and stores first-page previews for PDF files
+type PreviewService struct {
+	config      config.PreviewConfig
+	fileService *FileService
+}
+
+// NewPreviewService creates a new preview service instance
+func NewPreviewService() *PreviewService {
+	return &PreviewService{
+		config:      config.GetConfig().Storage.Preview,
+		fileService: NewFileService(),
+	}
+}
+
+// IsEnabled reports whether preview rendering is enabled
+func (s *PreviewService) IsEnabled() bool {
+	return s.config.Enabled
+}
+
+// IsAvailable reports whether the configured renderer can be found. Without it, previews can't be
+// rendered and are reported as unavailable instead of failing each time.
+func (s *PreviewService) IsAvailable() bool {
+	_, err := exec.LookPath(s.getRenderer())
+	return err == nil
+}
+
+// RenderOnUpload reports whether previews should be rendered at upload time
+func (s *PreviewService) RenderOnUpload() bool {
+	return s.config.Enabled && s.config.OnUpload && s.IsAvailable()
+}
+
+// getRenderer returns the executable that renders previews
+func (s *PreviewService) getRenderer() string {
+	if s.config.Renderer == "" {
+		return "pdftoppm"
+	}
+	return s.config.Renderer
+}
+
+// SupportsFile reports whether a preview can be rendered for the file
+func (s *PreviewService) SupportsFile(file *models.File) bool {
+	return file.Extension == "pdf" || file.MimeType == "application/pdf"
+}
+
+// GetPreviewPath returns the path where the preview for a file is stored
+func (s *PreviewService) GetPreviewPath(file *models.File) string {
+	return filepath.Join(s.config.OutputDir, file.ID.String()+".png")
+}
+
+// EnsurePreview returns the preview path for a file, rendering it if it does not exist yet
+func (s *PreviewService) EnsurePreview(file *models.File) (string, error) {
+	previewPath := s.GetPreviewPath(file)
+	if _, err := os.Stat(previewPath); err == nil {
+		return previewPath, nil
+	}
+
+	if err := s.RenderPreview(file); err != nil {
+		return "", err
+	}
+
+	return previewPath, nil
+}
+
+// RenderAsync renders a file's preview in the background
+func (s *PreviewService) RenderAsync(file models.File) {
+	go func() {
+		if err := s.RenderPreview(&file); err != nil {
+			log.Printf("Warning: Failed to render preview for %s: %v", file.OriginalName, err)
+		}
+	}()
+}
+
+// RenderPreview renders the first page of a PDF file to a PNG preview
+func (s *PreviewService) RenderPreview(file *models.File) error {
+	if !s.SupportsFile(file) {
+		return errors.BadRequestError("PREVIEW_UNSUPPORTED", "Previews are only available for PDF files")
+	}
+
+	if err := os.MkdirAll(s.config.OutputDir, 0755); err != nil {
+		return errors.InternalError("DIR_CREATION_ERROR", fmt.Sprintf("Failed to create preview directory: %v", err))
+	}
+
+	width := s.config.Width
+	if width <= 0 {
+		width = 480
+	}
+
+	// The renderer needs a local path, so it reads a copy of the content taken through the backend
+	sourcePath, err := s.fileService.CopyToTemp(file)
+	if err != nil {
+		return errors.InternalError("FILE_OPEN_ERROR", fmt.Sprintf("Failed to read file: %v", err))
+	}
+	defer os.Remove(sourcePath)
+
+	// Render under a unique temporary name so readers never see a partial preview, even when an
+	// upload and a first request render the same file at once. pdftoppm appends the .png extension
+	// to the output prefix itself.
+	previewPath := s.GetPreviewPath(file)
+	outputPrefix := fmt.Sprintf("%s.%d.tmp", strings.TrimSuffix(previewPath, ".png"), time.Now().UnixNano())
+	tmpPath := outputPrefix + ".png"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.getRenderer(),
+		"-png", "-f", "1", "-l", "1", "-singlefile",
+		"-scale-to-x", strconv.Itoa(width), "-scale-to-y", "-1",
+		sourcePath, outputPrefix)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmpPath)
+		return errors.InternalError("PREVIEW_RENDER_ERROR", fmt.Sprintf("Failed to render preview: %v: %s", err, strings.TrimSpace(string(output))))
+	}
+
+	if err := os.Rename(tmpPath, previewPath); err != nil {
+		os.Remove(tmpPath)
+		return errors.InternalError("PREVIEW_RENDER_ERROR", fmt.Sprintf("Failed to store preview: %v", err))
+	}
+
+	return nil
+}
+
+// DeletePreview removes the stored preview for a file if one exists
+func (s *PreviewService) DeletePreview(file *models.File) error {
+	if err := os.Remove(s.GetPreviewPath(file)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
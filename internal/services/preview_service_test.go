@@ -0,0 +1,22 @@
+package services
+
+import (
+	"testing"
+
+	"storage-api/internal/config"
+)
+
+func TestPreviewRendererMissing(t *testing.T) {
+	s := &PreviewService{config: config.PreviewConfig{
+		Enabled:  true,
+		OnUpload: true,
+		Renderer: "storage-api-missing-renderer",
+	}}
+
+	if s.IsAvailable() {
+		t.Error("IsAvailable() = true for a renderer that isn't installed")
+	}
+	if s.RenderOnUpload() {
+		t.Error("RenderOnUpload() = true for a renderer that isn't installed")
+	}
+}
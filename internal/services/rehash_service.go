@@ -0,0 +1,183 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"gorm.io/gorm"
+)
+
+var (
+	rehashMutex   sync.Mutex
+	rehashRunning bool
+)
+
+// RehashProgress describes the state of the re-hashing migration
+type RehashProgress struct {
+	Algorithm  string                      `json:"algorithm"`
+	Running    bool                        `json:"running"`
+	Remaining  int64                       `json:"remaining"`
+	Checkpoint *models.MigrationCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// RehashService recomputes stored file hashes after the hash algorithm changes.
+// Progress is checkpointed after every batch so an interrupted run resumes where it stopped.
+type RehashService struct {
+	fileService *FileService
+	config      config.RehashConfig
+}
+
+// NewRehashService creates a new rehash service instance
+func NewRehashService() *RehashService {
+	return &RehashService{
+		fileService: NewFileService(),
+		config:      config.GetConfig().Storage.Hashing.Rehash,
+	}
+}
+
+// checkpointName returns the checkpoint name for migrating to the configured algorithm
+func (s *RehashService) checkpointName() string {
+	return "rehash:" + s.fileService.GetHashAlgorithm()
+}
+
+// Start launches the migration in the background unless it is already running
+func (s *RehashService) Start() error {
+	rehashMutex.Lock()
+	defer rehashMutex.Unlock()
+
+	if rehashRunning {
+		return errors.ConflictError("REHASH_RUNNING", "Rehash migration is already running")
+	}
+	rehashRunning = true
+
+	go func() {
+		defer func() {
+			rehashMutex.Lock()
+			rehashRunning = false
+			rehashMutex.Unlock()
+		}()
+
+		if err := s.run(); err != nil {
+			log.Printf("Rehash migration stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// ResumePending restarts a migration that was interrupted before completing
+func (s *RehashService) ResumePending() {
+	var checkpoint models.MigrationCheckpoint
+	err := database.DB.Where("name = ?", s.checkpointName()).First(&checkpoint).Error
+	if err != nil || checkpoint.Completed {
+		return
+	}
+
+	log.Printf("Resuming rehash migration to %s after %d processed files", s.fileService.GetHashAlgorithm(), checkpoint.Processed)
+	if err := s.Start(); err != nil {
+		log.Printf("Failed to resume rehash migration: %v", err)
+	}
+}
+
+// GetProgress returns the current migration progress
+func (s *RehashService) GetProgress() (*RehashProgress, error) {
+	algorithm := s.fileService.GetHashAlgorithm()
+
+	rehashMutex.Lock()
+	progress := &RehashProgress{
+		Algorithm: algorithm,
+		Running:   rehashRunning,
+	}
+	rehashMutex.Unlock()
+
+	if err := database.DB.Model(&models.File{}).Where("hash_algorithm <> ?", algorithm).Count(&progress.Remaining).Error; err != nil {
+		return nil, err
+	}
+
+	var checkpoint models.MigrationCheckpoint
+	if err := database.DB.Where("name = ?", s.checkpointName()).First(&checkpoint).Error; err == nil {
+		progress.Checkpoint = &checkpoint
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// run processes files in keyset-ordered batches, saving a checkpoint after each batch
+func (s *RehashService) run() error {
+	algorithm := s.fileService.GetHashAlgorithm()
+
+	checkpoint := models.MigrationCheckpoint{Name: s.checkpointName()}
+	if err := database.DB.Where("name = ?", checkpoint.Name).FirstOrCreate(&checkpoint).Error; err != nil {
+		return err
+	}
+	if checkpoint.Completed {
+		// A previous run finished; start over to pick up files added with an older algorithm since
+		checkpoint.Completed = false
+		checkpoint.CompletedAt = nil
+		checkpoint.LastID = ""
+	}
+
+	batchSize := s.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	var throttle time.Duration
+	if s.config.MaxFilesPerSecond > 0 {
+		throttle = time.Second / time.Duration(s.config.MaxFilesPerSecond)
+	}
+
+	for {
+		query := database.DB.Where("hash_algorithm <> ?", algorithm).Order("id ASC").Limit(batchSize)
+		if checkpoint.LastID != "" {
+			query = query.Where("id > ?", checkpoint.LastID)
+		}
+
+		var files []models.File
+		if err := query.Find(&files).Error; err != nil {
+			return err
+		}
+
+		if len(files) == 0 {
+			now := time.Now()
+			checkpoint.Completed = true
+			checkpoint.CompletedAt = &now
+			log.Printf("Rehash migration to %s completed: %d processed, %d failed", algorithm, checkpoint.Processed, checkpoint.Failed)
+			return database.DB.Save(&checkpoint).Error
+		}
+
+		for _, file := range files {
+			hash, err := s.fileService.CalculateFileHashWithAlgorithm(file.Backend, file.FilePath, algorithm)
+			if err == nil {
+				err = database.DB.Model(&file).Updates(map[string]interface{}{
+					"hash":           hash,
+					"hash_algorithm": algorithm,
+				}).Error
+			}
+
+			if err != nil {
+				log.Printf("Warning: Failed to rehash file %s: %v", file.ID, err)
+				checkpoint.Failed++
+			} else {
+				checkpoint.Processed++
+			}
+			checkpoint.LastID = file.ID.String()
+
+			if throttle > 0 {
+				time.Sleep(throttle)
+			}
+		}
+
+		if err := database.DB.Save(&checkpoint).Error; err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+var (
+	reindexMutex  sync.Mutex
+	reindexStatus ReindexStatus
+)
+
+// ReindexStatus describes the current or last search field reindex run
+type ReindexStatus struct {
+	Running          bool       `json:"running"`
+	StartedAt        *time.Time `json:"startedAt,omitempty"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+	Total            int64      `json:"total"`
+	Processed        int64      `json:"processed"`
+	NamesNormalized  int64      `json:"namesNormalized"`
+	FileTypesUpdated int64      `json:"fileTypesUpdated"`
+	ContentIndexed   int64      `json:"contentIndexed"`
+	Errors           int64      `json:"errors"`
+}
+
+// ReindexService backfills the fields search relies on for files stored before they existed or
+// were enabled: normalized original names, file types and extracted document text
+type ReindexService struct {
+	fileService  *FileService
+	contentIndex *ContentIndexService
+	config       config.ReindexConfig
+}
+
+// NewReindexService creates a new reindex service instance
+func NewReindexService() *ReindexService {
+	return &ReindexService{
+		fileService:  NewFileService(),
+		contentIndex: NewContentIndexService(),
+		config:       config.GetConfig().Storage.Reindex,
+	}
+}
+
+// Start launches a reindex run in the background unless one is already running
+func (s *ReindexService) Start() error {
+	reindexMutex.Lock()
+	defer reindexMutex.Unlock()
+
+	if reindexStatus.Running {
+		return errors.ConflictError("REINDEX_RUNNING", "Search reindex is already running")
+	}
+
+	now := time.Now()
+	reindexStatus = ReindexStatus{Running: true, StartedAt: &now}
+
+	go func() {
+		err := s.run()
+
+		reindexMutex.Lock()
+		completedAt := time.Now()
+		reindexStatus.Running = false
+		reindexStatus.CompletedAt = &completedAt
+		reindexMutex.Unlock()
+
+		if err != nil {
+			log.Printf("Search reindex stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// GetStatus returns the state of the current or last run
+func (s *ReindexService) GetStatus() ReindexStatus {
+	reindexMutex.Lock()
+	defer reindexMutex.Unlock()
+	return reindexStatus
+}
+
+// run reindexes every file in keyset-ordered batches
+func (s *ReindexService) run() error {
+	var total int64
+	if err := database.DB.Model(&models.File{}).Count(&total).Error; err != nil {
+		return err
+	}
+	reindexMutex.Lock()
+	reindexStatus.Total = total
+	reindexMutex.Unlock()
+
+	batchSize := s.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	lastID := ""
+	for {
+		query := database.DB.Order("id ASC").Limit(batchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+
+		var files []models.File
+		if err := query.Find(&files).Error; err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			status := s.GetStatus()
+			log.Printf("Search reindex completed: %d processed, %d names normalized, %d file types updated, %d documents indexed, %d errors",
+				status.Processed, status.NamesNormalized, status.FileTypesUpdated, status.ContentIndexed, status.Errors)
+			return nil
+		}
+
+		for _, file := range files {
+			s.reindex(&file)
+			lastID = file.ID.String()
+		}
+	}
+}
+
+// reindex refreshes the derived search fields of one file and records the outcome
+func (s *ReindexService) reindex(file *models.File) {
+	var normalized, retyped, indexed, failed bool
+
+	// Names stored before Unicode normalization was enabled are normalized like new uploads
+	if file.RawOriginalName == "" {
+		if name, raw := s.fileService.NormalizeOriginalName(file.OriginalName); raw != "" {
+			err := database.DB.Model(file).UpdateColumns(map[string]interface{}{
+				"original_name":     name,
+				"raw_original_name": raw,
+			}).Error
+			if err != nil {
+				log.Printf("Warning: Failed to normalize name of file %s: %v", file.ID, err)
+				failed = true
+			} else {
+				normalized = true
+			}
+		}
+	}
+
+	// File types follow the current setting, so switching to rule categories (or back) regroups
+	// existing files; their stored location is left unchanged. The recorded extension is used since
+	// stored names may not carry one.
+	if fileType := s.fileService.DeriveFileType("file." + file.Extension); file.Extension != "" && fileType != file.FileType {
+		if err := database.DB.Model(file).UpdateColumn("file_type", fileType).Error; err != nil {
+			log.Printf("Warning: Failed to update file type of file %s: %v", file.ID, err)
+			failed = true
+		} else {
+			retyped = true
+		}
+	}
+
+	// Extracted text is refreshed, so documents indexed by an older extractor pick up its fixes
+	if s.contentIndex.IsEnabled() && s.contentIndex.SupportsFile(file) {
+		if err := s.contentIndex.IndexFile(file); err != nil {
+			log.Printf("Warning: Failed to index content of file %s: %v", file.ID, err)
+			failed = true
+		} else {
+			indexed = true
+		}
+	}
+
+	reindexMutex.Lock()
+	defer reindexMutex.Unlock()
+	reindexStatus.Processed++
+	if normalized {
+		reindexStatus.NamesNormalized++
+	}
+	if retyped {
+		reindexStatus.FileTypesUpdated++
+	}
+	if indexed {
+		reindexStatus.ContentIndexed++
+	}
+	if failed {
+		reindexStatus.Errors++
+	}
+}
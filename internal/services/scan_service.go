@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+)
+
+// ScanService scans uploaded files for malware in the background.
+// While a scan is pending the file stays quarantined and cannot be downloaded.
+type ScanService struct {
+	config config.ScanningConfig
+}
+
+// NewScanService creates a new scan service instance
+func NewScanService() *ScanService {
+	return &ScanService{
+		config: config.GetConfig().Storage.Scanning,
+	}
+}
+
+// IsEnabled reports whether uploads are scanned before becoming available
+func (s *ScanService) IsEnabled() bool {
+	return s.config.Enabled
+}
+
+// InitialStatus returns the status a newly uploaded file should start in, given the status it
+// should have once it is known to be clean
+func (s *ScanService) InitialStatus(cleanStatus string) string {
+	if s.config.Enabled {
+		return "quarantined"
+	}
+	return cleanStatus
+}
+
+// ScanAsync scans a quarantined file in the background and updates its status with the verdict.
+// Clean files move to cleanStatus.
+func (s *ScanService) ScanAsync(file models.File, cleanStatus string) {
+	go s.scanAndUpdate(file, cleanStatus)
+}
+
+// ResumePending rescans files left quarantined by a previous shutdown
+func (s *ScanService) ResumePending() {
+	if !s.config.Enabled {
+		return
+	}
+
+	var files []models.File
+	if err := database.DB.Where("status = ?", "quarantined").Find(&files).Error; err != nil {
+		log.Printf("Failed to load quarantined files: %v", err)
+		return
+	}
+
+	if len(files) > 0 {
+		log.Printf("Resuming virus scans for %d quarantined files", len(files))
+	}
+
+	// The status requested at upload isn't persisted, so resumed files that scan clean move to
+	// the configured default. Scan sequentially so a large backlog doesn't overwhelm the scanner.
+	cleanStatus := config.GetConfig().Storage.Upload.GetDefaultStatus()
+	go func() {
+		for _, file := range files {
+			s.scanAndUpdate(file, cleanStatus)
+		}
+	}()
+}
+
+// scanAndUpdate runs the scanner and transitions the file to cleanStatus or infected.
+// Scanner failures leave the file quarantined so it is retried on the next startup.
+func (s *ScanService) scanAndUpdate(file models.File, cleanStatus string) {
+	clean, err := s.Scan(file.FilePath)
+	if err != nil {
+		log.Printf("Warning: Failed to scan file %s: %v", file.ID, err)
+		return
+	}
+
+	status := cleanStatus
+	if !clean {
+		status = "infected"
+		log.Printf("Warning: File %s (%s) failed virus scan", file.ID, file.OriginalName)
+	}
+
+	// Only transition files still quarantined so manual status changes aren't overwritten
+	if err := database.WithRetry(func() error {
+		return database.DB.Model(&models.File{}).
+			Where("id = ? AND status = ?", file.ID, "quarantined").
+			Update("status", status).Error
+	}); err != nil {
+		log.Printf("Warning: Failed to update scan status for file %s: %v", file.ID, err)
+	}
+}
+
+// Scan runs the configured scanner against a file and reports whether it is clean
+func (s *ScanService) Scan(path string) (bool, error) {
+	scanner := s.config.Scanner
+	if scanner == "" {
+		scanner = "clamdscan"
+	}
+
+	timeout := time.Duration(s.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, scanner, "--no-summary", path).CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+
+	// Exit code 1 is the conventional "virus found" verdict; anything else is a scanner error
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"storage-api/internal/config"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// DefaultBackendName is the name of the backend built from the top-level storage settings
+const DefaultBackendName = "local"
+
+// StorageBackend stores and removes file content for a storage location
+type StorageBackend interface {
+	// Name returns the backend name recorded on each file
+	Name() string
+	// Type returns the kind of backend (e.g. "local")
+	Type() string
+	// BaseDir returns the directory under which the backend places files
+	BaseDir() string
+	// Save writes the content of src to a new file at path; an existing file is never replaced
+	Save(src io.Reader, path string) error
+	// Open opens the file at path for reading
+	Open(path string) (io.ReadCloser, error)
+	// Stat describes the file at path; a missing file yields an error satisfying os.IsNotExist
+	Stat(path string) (os.FileInfo, error)
+	// Delete removes the file at path
+	Delete(path string) error
+}
+
+// localDirMutex serializes directory pruning against uploads creating files in those directories.
+// Saves hold it shared between creating a directory and creating the file in it; pruning holds it exclusively.
+var localDirMutex sync.RWMutex
+
+// LocalBackend stores files on a local or mounted filesystem
+type LocalBackend struct {
+	name           string
+	uploadDir      string
+	createDirs     bool
+	pruneEmptyDirs bool
+}
+
+// NewLocalBackend creates a new filesystem backend rooted at uploadDir
+func NewLocalBackend(name, uploadDir string, createDirs, pruneEmptyDirs bool) *LocalBackend {
+	return &LocalBackend{
+		name:           name,
+		uploadDir:      uploadDir,
+		createDirs:     createDirs,
+		pruneEmptyDirs: pruneEmptyDirs,
+	}
+}
+
+// Name returns the backend name
+func (b *LocalBackend) Name() string {
+	return b.name
+}
+
+// Type returns the backend type
+func (b *LocalBackend) Type() string {
+	return "local"
+}
+
+// BaseDir returns the backend's upload directory
+func (b *LocalBackend) BaseDir() string {
+	return b.uploadDir
+}
+
+// Save writes the content of src to a new file at path, creating parent directories if configured.
+// The file is created exclusively, so a name already taken by another upload is refused rather
+// than overwritten, and a failed write only removes what this call created.
+func (b *LocalBackend) Save(src io.Reader, path string) error {
+	dst, err := b.createFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return errors.InternalError("FILE_COPY_ERROR", fmt.Sprintf("Failed to copy file content: %v", err))
+	}
+
+	return nil
+}
+
+// Open opens the file at path for reading
+func (b *LocalBackend) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// Stat describes the file at path
+func (b *LocalBackend) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// createFile creates the destination file and its parent directories while holding off pruning
+func (b *LocalBackend) createFile(path string) (*os.File, error) {
+	localDirMutex.RLock()
+	defer localDirMutex.RUnlock()
+
+	if b.createDirs {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, errors.InternalError("DIR_CREATION_ERROR", fmt.Sprintf("Failed to create directory: %v", err))
+		}
+	}
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return nil, errors.ConflictError("FILE_EXISTS", fmt.Sprintf("A stored file named %s already exists", filepath.Base(path)))
+	}
+	if err != nil {
+		return nil, errors.InternalError("FILE_CREATION_ERROR", fmt.Sprintf("Failed to create destination file: %v", err))
+	}
+
+	return dst, nil
+}
+
+// Delete removes the file at path and, if configured, any parent directories left empty
+func (b *LocalBackend) Delete(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	if b.pruneEmptyDirs {
+		b.pruneEmptyParents(filepath.Dir(path))
+	}
+	return nil
+}
+
+// pruneEmptyParents removes empty directories from dir upwards, stopping below the upload directory.
+// os.Remove refuses non-empty directories, so directories that still hold files are left alone.
+func (b *LocalBackend) pruneEmptyParents(dir string) {
+	root, err := filepath.Abs(b.uploadDir)
+	if err != nil {
+		return
+	}
+
+	localDirMutex.Lock()
+	defer localDirMutex.Unlock()
+
+	for {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return
+		}
+
+		// Only prune directories strictly inside the upload directory
+		rel, err := filepath.Rel(root, absDir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+
+		if err := os.Remove(absDir); err != nil {
+			return
+		}
+		dir = filepath.Dir(absDir)
+	}
+}
+
+// newStorageBackends builds the named backends from configuration, always including the default one
+func newStorageBackends(storageConfig config.LocalStorageConfig) map[string]StorageBackend {
+	backends := map[string]StorageBackend{
+		DefaultBackendName: NewLocalBackend(DefaultBackendName, storageConfig.UploadDir, storageConfig.CreateDirs, storageConfig.PruneEmptyDirs),
+	}
+
+	for _, backendConfig := range storageConfig.Backends {
+		switch backendConfig.Type {
+		case "", "local":
+			backends[backendConfig.Name] = NewLocalBackend(backendConfig.Name, backendConfig.UploadDir, backendConfig.CreateDirs, storageConfig.PruneEmptyDirs)
+		default:
+			log.Printf("Warning: Ignoring storage backend %q with unsupported type %q", backendConfig.Name, backendConfig.Type)
+		}
+	}
+
+	return backends
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+func TestLocalBackendSaveExclusive(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewLocalBackend(DefaultBackendName, dir, true, false)
+	path := filepath.Join(dir, "docs", "report.pdf")
+
+	if err := backend.Save(strings.NewReader("first"), path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	err := backend.Save(strings.NewReader("second"), path)
+	if !errors.IsCode(err, "FILE_EXISTS") {
+		t.Errorf("Save() over an existing file error = %v, want FILE_EXISTS", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "first" {
+		t.Errorf("existing file holds %q (%v), want it untouched", data, err)
+	}
+}
+
+// failingReader returns an error after some content has been read
+type failingReader struct{ read bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, os.ErrClosed
+	}
+	r.read = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalBackendSaveFailureRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewLocalBackend(DefaultBackendName, dir, false, false)
+	path := filepath.Join(dir, "report.pdf")
+
+	if err := backend.Save(&failingReader{}, path); err == nil {
+		t.Fatal("Save() error = nil, want the read error")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+}
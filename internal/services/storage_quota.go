@@ -0,0 +1,62 @@
+package services
+
+import (
+	"storage-api/internal/config"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+)
+
+// StorageQuota reports how much of the configured storage quota stored files use. It is a soft
+// quota: uploads are never rejected, clients are only warned as usage approaches the limit.
+type StorageQuota struct {
+	limit       int64
+	warnPercent int
+}
+
+// StorageQuotaUsage describes the stored bytes against the quota
+type StorageQuotaUsage struct {
+	Used    int64
+	Limit   int64
+	Warning bool
+}
+
+// NewStorageQuota creates a new storage quota from the upload configuration
+func NewStorageQuota() *StorageQuota {
+	quotaConfig := config.GetConfig().Storage.Upload.Quota
+
+	warnPercent := quotaConfig.WarnPercent
+	if warnPercent <= 0 || warnPercent > 100 {
+		warnPercent = 80
+	}
+
+	return &StorageQuota{
+		limit:       parseOptionalSize(quotaConfig.Limit),
+		warnPercent: warnPercent,
+	}
+}
+
+// IsEnabled reports whether a quota limit is configured
+func (q *StorageQuota) IsEnabled() bool {
+	return q.limit > 0
+}
+
+// GetUsage sums the size of all file records and compares it with the limit. Usage is read from
+// the database on every call, so it is only worth calling after uploads.
+func (q *StorageQuota) GetUsage() (*StorageQuotaUsage, error) {
+	var used int64
+	if err := database.DB.Model(&models.File{}).Select("COALESCE(SUM(file_size), 0)").Scan(&used).Error; err != nil {
+		return nil, err
+	}
+
+	usage := &StorageQuotaUsage{
+		Used:    used,
+		Limit:   q.limit,
+		Warning: used*100 >= q.limit*int64(q.warnPercent),
+	}
+	return usage, nil
+}
+
+// GetWarnPercent returns the usage percentage from which clients are warned
+func (q *StorageQuota) GetWarnPercent() int {
+	return q.warnPercent
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"storage-api/internal/config"
+	"storage-api/internal/constants"
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagService manages file tags, including category tags applied automatically on upload
+type TagService struct {
+	config           config.TaggingConfig
+	validationEngine *constants.ValidationEngine
+}
+
+// NewTagService creates a new tag service instance
+func NewTagService() *TagService {
+	storageConfig := config.GetConfig().Storage
+	return &TagService{
+		config:           storageConfig.Tagging,
+		validationEngine: constants.NewValidationEngine(storageConfig.Validation),
+	}
+}
+
+// getMaxTags returns the maximum number of tags a file may carry
+func (s *TagService) getMaxTags() int {
+	if s.config.MaxTagsPerFile <= 0 {
+		return 20
+	}
+	return s.config.MaxTagsPerFile
+}
+
+// getMaxTagLength returns the maximum length of a single tag
+func (s *TagService) getMaxTagLength() int {
+	if s.config.MaxTagLength <= 0 {
+		return 64
+	}
+	return s.config.MaxTagLength
+}
+
+// ParseTags splits comma-separated tag lists and normalizes the result
+func (s *TagService) ParseTags(values ...string) ([]string, error) {
+	var raw []string
+	for _, value := range values {
+		raw = append(raw, strings.Split(value, ",")...)
+	}
+	return s.NormalizeTags(raw)
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty entries and duplicates
+func (s *TagService) NormalizeTags(raw []string) ([]string, error) {
+	seen := make(map[string]bool, len(raw))
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > s.getMaxTagLength() {
+			return nil, errors.BadRequestError("TAG_TOO_LONG", fmt.Sprintf("Tag %q exceeds %d characters", tag, s.getMaxTagLength()))
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > s.getMaxTags() {
+		return nil, errors.BadRequestError("TOO_MANY_TAGS", fmt.Sprintf("Maximum %d tags allowed per file", s.getMaxTags()))
+	}
+	return tags, nil
+}
+
+// WithCategoryTag adds the category tag of the file's matching validation rule to the client's
+// tags when auto-tagging is enabled
+func (s *TagService) WithCategoryTag(fileName string, tags []string) []string {
+	if !s.config.AutoTagByCategory {
+		return tags
+	}
+
+	validationResult := s.validationEngine.ValidateFile(fileName, "", 0)
+	if validationResult.MatchedRule == nil {
+		return tags
+	}
+
+	category := validationResult.MatchedRule.Tag
+	if category == "" {
+		category = validationResult.MatchedRule.Name
+	}
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return tags
+	}
+
+	for _, tag := range tags {
+		if tag == category {
+			return tags
+		}
+	}
+	return append(tags, category)
+}
+
+// AddTags attaches tags to a file, ignoring tags it already has
+func (s *TagService) AddTags(fileID uuid.UUID, tags []string) error {
+	return addTags(database.DB, fileID, tags)
+}
+
+// addTags inserts tag rows for a file using the given connection or transaction
+func addTags(db *gorm.DB, fileID uuid.UUID, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]models.FileTag, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, models.FileTag{FileID: fileID, Tag: tag})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// BulkTagResult reports the outcome of a bulk tag update for one file
+type BulkTagResult struct {
+	ID      string   `json:"id"`
+	Success bool     `json:"success"`
+	Tags    []string `json:"tags,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// BulkUpdateTags adds and removes tags on several files in a single transaction. Files that
+// don't exist or would exceed the tag limit are reported as failed without affecting the others.
+func (s *TagService) BulkUpdateTags(fileIDs []uuid.UUID, add, remove []string) ([]BulkTagResult, error) {
+	results := make([]BulkTagResult, 0, len(fileIDs))
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, fileID := range fileIDs {
+			result := BulkTagResult{ID: fileID.String()}
+
+			var count int64
+			if err := tx.Model(&models.File{}).Where("id = ?", fileID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				result.Error = "File not found"
+				results = append(results, result)
+				continue
+			}
+
+			var current []string
+			if err := tx.Model(&models.FileTag{}).Where("file_id = ?", fileID).Pluck("tag", &current).Error; err != nil {
+				return err
+			}
+
+			// Check the limit against the resulting set before changing anything
+			tags := applyTagChanges(current, add, remove)
+			if len(tags) > s.getMaxTags() {
+				result.Error = fmt.Sprintf("Maximum %d tags allowed per file", s.getMaxTags())
+				results = append(results, result)
+				continue
+			}
+
+			if len(remove) > 0 {
+				if err := tx.Unscoped().Where("file_id = ? AND tag IN ?", fileID, remove).Delete(&models.FileTag{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := addTags(tx, fileID, add); err != nil {
+				return err
+			}
+
+			result.Success = true
+			result.Tags = tags
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteTags removes all tags of a file. Rows are removed permanently so the unique index
+// doesn't keep a re-added tag from being stored.
+func (s *TagService) DeleteTags(fileID uuid.UUID) error {
+	return database.DB.Unscoped().Where("file_id = ?", fileID).Delete(&models.FileTag{}).Error
+}
+
+// applyTagChanges returns the sorted tag set resulting from removing and then adding tags
+func applyTagChanges(current, add, remove []string) []string {
+	set := make(map[string]bool, len(current)+len(add))
+	for _, tag := range current {
+		set[tag] = true
+	}
+	for _, tag := range remove {
+		delete(set, tag)
+	}
+	for _, tag := range add {
+		set[tag] = true
+	}
+
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// GetTags returns the tags of a file in alphabetical order
+func (s *TagService) GetTags(fileID uuid.UUID) ([]string, error) {
+	var tags []string
+	err := database.DB.Model(&models.FileTag{}).Where("file_id = ?", fileID).Order("tag").Pluck("tag", &tags).Error
+	return tags, err
+}
+
+// AttachTags loads the tags of the given files into their Tags field
+func (s *TagService) AttachTags(files []models.File) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	fileIDs := make([]uuid.UUID, 0, len(files))
+	for _, file := range files {
+		fileIDs = append(fileIDs, file.ID)
+	}
+
+	var rows []models.FileTag
+	if err := database.DB.Where("file_id IN ?", fileIDs).Order("tag").Find(&rows).Error; err != nil {
+		return err
+	}
+
+	tagsByFile := make(map[uuid.UUID][]string, len(files))
+	for _, row := range rows {
+		tagsByFile[row.FileID] = append(tagsByFile[row.FileID], row.Tag)
+	}
+	for i := range files {
+		files[i].Tags = tagsByFile[files[i].ID]
+	}
+	return nil
+}
+
+// FilterByTag restricts a file query to files carrying the tag
+func (s *TagService) FilterByTag(query *gorm.DB, tag string) *gorm.DB {
+	return query.Where("id IN (SELECT file_id FROM file_tags WHERE tag = ? AND deleted_at IS NULL)", strings.ToLower(strings.TrimSpace(tag)))
+}
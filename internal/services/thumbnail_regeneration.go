@@ -0,0 +1,166 @@
+package services
+
+import (
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"storage-api/internal/database"
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"gorm.io/gorm"
+)
+
+var (
+	thumbnailRegenerationMutex  sync.Mutex
+	thumbnailRegenerationStatus ThumbnailRegenerationStatus
+)
+
+// ThumbnailRegenerationStatus describes the current or last thumbnail regeneration run
+type ThumbnailRegenerationStatus struct {
+	Running     bool       `json:"running"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Total       int64      `json:"total"`
+	Processed   int64      `json:"processed"`
+	Regenerated int64      `json:"regenerated"`
+	Missing     int64      `json:"missing"`
+	Errors      int64      `json:"errors"`
+}
+
+// ThumbnailFilter narrows thumbnail regeneration to a subset of image files
+type ThumbnailFilter struct {
+	Folder     string
+	Extensions []string
+}
+
+// CountRegeneration returns the number of image files a regeneration run with the filter would process
+func (s *ThumbnailService) CountRegeneration(filter ThumbnailFilter) (int64, error) {
+	var total int64
+	if err := s.regenerationQuery(filter).Model(&models.File{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// StartRegeneration launches a background run that renders the thumbnails of the filtered image
+// files again with the current settings, unless one is already running
+func (s *ThumbnailService) StartRegeneration(filter ThumbnailFilter) error {
+	thumbnailRegenerationMutex.Lock()
+	defer thumbnailRegenerationMutex.Unlock()
+
+	if thumbnailRegenerationStatus.Running {
+		return errors.ConflictError("THUMBNAIL_REGENERATION_RUNNING", "Thumbnail regeneration is already running")
+	}
+
+	now := time.Now()
+	thumbnailRegenerationStatus = ThumbnailRegenerationStatus{Running: true, StartedAt: &now}
+
+	go func() {
+		err := s.runRegeneration(filter)
+
+		thumbnailRegenerationMutex.Lock()
+		completedAt := time.Now()
+		thumbnailRegenerationStatus.Running = false
+		thumbnailRegenerationStatus.CompletedAt = &completedAt
+		thumbnailRegenerationMutex.Unlock()
+
+		if err != nil {
+			log.Printf("Thumbnail regeneration stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// GetRegenerationStatus returns the state of the current or last regeneration run
+func (s *ThumbnailService) GetRegenerationStatus() ThumbnailRegenerationStatus {
+	thumbnailRegenerationMutex.Lock()
+	defer thumbnailRegenerationMutex.Unlock()
+	return thumbnailRegenerationStatus
+}
+
+// regenerationQuery selects the image files matching the filter. Files still being scanned or
+// flagged as infected are left alone.
+func (s *ThumbnailService) regenerationQuery(filter ThumbnailFilter) *gorm.DB {
+	var extensions []string
+	for ext := range thumbnailExtensions {
+		if len(filter.Extensions) == 0 || slices.Contains(filter.Extensions, ext) {
+			extensions = append(extensions, ext)
+		}
+	}
+
+	query := database.DB.Where("extension IN ?", extensions).Where("status NOT IN ?", []string{"quarantined", "infected"})
+	if filter.Folder != "" {
+		query = query.Where("folder = ?", filter.Folder)
+	}
+	return query
+}
+
+// runRegeneration regenerates thumbnails in keyset-ordered batches
+func (s *ThumbnailService) runRegeneration(filter ThumbnailFilter) error {
+	total, err := s.CountRegeneration(filter)
+	if err != nil {
+		return err
+	}
+	thumbnailRegenerationMutex.Lock()
+	thumbnailRegenerationStatus.Total = total
+	thumbnailRegenerationMutex.Unlock()
+
+	batchSize := s.config.RegenerateBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	lastID := ""
+	for {
+		query := s.regenerationQuery(filter).Order("id ASC").Limit(batchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+
+		var files []models.File
+		if err := query.Find(&files).Error; err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			status := s.GetRegenerationStatus()
+			log.Printf("Thumbnail regeneration completed: %d processed, %d regenerated, %d missing, %d errors",
+				status.Processed, status.Regenerated, status.Missing, status.Errors)
+			return nil
+		}
+
+		for _, file := range files {
+			s.regenerate(&file)
+			lastID = file.ID.String()
+		}
+	}
+}
+
+// regenerate replaces the thumbnail of one file and records the outcome
+func (s *ThumbnailService) regenerate(file *models.File) {
+	var missing bool
+	err := s.GenerateThumbnail(file)
+	if err != nil {
+		if _, statErr := s.fileService.GetBackend(file.Backend).Stat(file.FilePath); os.IsNotExist(statErr) {
+			missing = true
+		} else {
+			log.Printf("Warning: Failed to regenerate thumbnail of file %s: %v", file.ID, err)
+		}
+	}
+
+	thumbnailRegenerationMutex.Lock()
+	defer thumbnailRegenerationMutex.Unlock()
+	thumbnailRegenerationStatus.Processed++
+	switch {
+	case err == nil:
+		thumbnailRegenerationStatus.Regenerated++
+	case missing:
+		thumbnailRegenerationStatus.Missing++
+	default:
+		thumbnailRegenerationStatus.Errors++
+	}
+}
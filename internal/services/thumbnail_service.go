@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+
+	"storage-api/internal/config"
+	"storage-api/internal/models"
+	"storage-api/internal/utils"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// thumbnailExtensions lists image extensions that can be decoded for thumbnail generation
+var thumbnailExtensions = map[string]bool{
+	"jpg":  true,
+	"jpeg": true,
+	"png":  true,
+	"gif":  true,
+}
+
+// ThumbnailService generates and stores JPEG thumbnails for image files
+type ThumbnailService struct {
+	config      config.ThumbnailConfig
+	fileService *FileService
+}
+
+// NewThumbnailService creates a new thumbnail service instance
+func NewThumbnailService() *ThumbnailService {
+	return &ThumbnailService{
+		config:      config.GetConfig().Storage.Thumbnails,
+		fileService: NewFileService(),
+	}
+}
+
+// GetMaxBatch returns the maximum number of thumbnails that can be requested at once
+func (s *ThumbnailService) GetMaxBatch() int {
+	if s.config.MaxBatch <= 0 {
+		return 50
+	}
+	return s.config.MaxBatch
+}
+
+// SupportsFile reports whether a thumbnail can be generated for the file
+func (s *ThumbnailService) SupportsFile(file *models.File) bool {
+	return thumbnailExtensions[file.Extension]
+}
+
+// GetThumbnailPath returns the path where the thumbnail for a file is stored
+func (s *ThumbnailService) GetThumbnailPath(file *models.File) string {
+	return filepath.Join(s.config.OutputDir, file.ID.String()+".jpg")
+}
+
+// EnsureThumbnail returns the thumbnail path for a file, generating it if it does not exist yet
+func (s *ThumbnailService) EnsureThumbnail(file *models.File) (string, error) {
+	thumbnailPath := s.GetThumbnailPath(file)
+	if _, err := os.Stat(thumbnailPath); err == nil {
+		return thumbnailPath, nil
+	}
+
+	if err := s.GenerateThumbnail(file); err != nil {
+		return "", err
+	}
+
+	return thumbnailPath, nil
+}
+
+// OpenThumbnail opens the thumbnail for a file, generating it if it does not exist yet
+func (s *ThumbnailService) OpenThumbnail(file *models.File) (io.ReadCloser, error) {
+	thumbnailPath, err := s.EnsureThumbnail(file)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(thumbnailPath)
+}
+
+// GenerateThumbnail renders a JPEG thumbnail for an image file, replacing any existing one
+func (s *ThumbnailService) GenerateThumbnail(file *models.File) error {
+	if !s.SupportsFile(file) {
+		return errors.BadRequestError("THUMBNAIL_UNSUPPORTED", fmt.Sprintf("Thumbnails are not supported for .%s files", file.Extension))
+	}
+
+	src, err := s.fileService.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return errors.InternalError("FILE_OPEN_ERROR", fmt.Sprintf("Failed to open image: %v", err))
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return errors.BadRequestError("IMAGE_DECODE_ERROR", fmt.Sprintf("Failed to decode image: %v", err))
+	}
+
+	size := s.config.Size
+	if size <= 0 {
+		size = 256
+	}
+	resized := utils.ResizeToFit(img, size)
+
+	// Flatten onto a white background since JPEG has no alpha channel
+	canvas := image.NewRGBA(resized.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), resized, resized.Bounds().Min, draw.Over)
+
+	if err := os.MkdirAll(s.config.OutputDir, 0755); err != nil {
+		return errors.InternalError("DIR_CREATION_ERROR", fmt.Sprintf("Failed to create thumbnail directory: %v", err))
+	}
+
+	quality := s.config.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 80
+	}
+
+	// Write to a temporary file first so readers never see a partial thumbnail
+	thumbnailPath := s.GetThumbnailPath(file)
+	tmpPath := thumbnailPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.InternalError("FILE_CREATION_ERROR", fmt.Sprintf("Failed to create thumbnail: %v", err))
+	}
+
+	if err := jpeg.Encode(dst, canvas, &jpeg.Options{Quality: quality}); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return errors.InternalError("THUMBNAIL_ENCODE_ERROR", fmt.Sprintf("Failed to encode thumbnail: %v", err))
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.InternalError("THUMBNAIL_ENCODE_ERROR", fmt.Sprintf("Failed to write thumbnail: %v", err))
+	}
+
+	if err := os.Rename(tmpPath, thumbnailPath); err != nil {
+		os.Remove(tmpPath)
+		return errors.InternalError("THUMBNAIL_ENCODE_ERROR", fmt.Sprintf("Failed to store thumbnail: %v", err))
+	}
+
+	return nil
+}
+
+// DeleteThumbnail removes the stored thumbnail for a file if one exists
+func (s *ThumbnailService) DeleteThumbnail(file *models.File) error {
+	if err := os.Remove(s.GetThumbnailPath(file)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"storage-api/internal/models"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetSampleSize is how much of a file is inspected to detect its charset
+const charsetSampleSize = 64 * 1024
+
+// transcodableExtensions lists text extensions eligible for charset conversion regardless of stored MIME type
+var transcodableExtensions = map[string]bool{
+	"txt": true,
+	"csv": true,
+	"tsv": true,
+}
+
+// IsTextFile reports whether a file is text and may be transcoded on download
+func IsTextFile(file *models.File) bool {
+	return strings.HasPrefix(file.MimeType, "text/") || transcodableExtensions[strings.ToLower(file.Extension)]
+}
+
+// TranscodedFile is a text file stream converted to a requested charset
+type TranscodedFile struct {
+	io.Reader
+	io.Closer
+	Charset string
+}
+
+// lookupCharset resolves a charset name; "utf-8-bom" selects UTF-8 with a byte order mark
+func lookupCharset(name string) (encoding.Encoding, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "utf-8-bom" || name == "utf8-bom" {
+		return unicode.UTF8BOM, "utf-8", nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, "", err
+	}
+	canonical, err := htmlindex.Name(enc)
+	if err != nil {
+		canonical = name
+	}
+	return enc, canonical, nil
+}
+
+// detectCharset guesses the source encoding from a sample: BOMs first, then UTF-8 validity,
+// falling back to the configured legacy charset
+func (s *FileService) detectCharset(sample []byte) (encoding.Encoding, error) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return unicode.UTF8BOM, nil
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), nil
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), nil
+	}
+
+	// A sample cut mid-character is still UTF-8; trim an incomplete trailing rune before checking
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return unicode.UTF8, nil
+	}
+
+	fallback := s.config.Download.FallbackCharset
+	if fallback == "" {
+		fallback = "windows-1252"
+	}
+	return htmlindex.Get(fallback)
+}
+
+// OpenTranscoded opens a text file converted from its detected charset to the requested one
+func (s *FileService) OpenTranscoded(file *models.File, charset string) (*TranscodedFile, error) {
+	if !IsTextFile(file) {
+		return nil, errors.BadRequestError("NOT_TEXT_FILE", "Charset conversion is only available for text files")
+	}
+
+	target, canonical, err := lookupCharset(charset)
+	if err != nil {
+		return nil, errors.BadRequestError("UNSUPPORTED_CHARSET", fmt.Sprintf("Unsupported charset: %s", charset))
+	}
+
+	f, err := s.GetBackend(file.Backend).Open(file.FilePath)
+	if err != nil {
+		return nil, errors.InternalError("FILE_OPEN_ERROR", fmt.Sprintf("Failed to open file: %v", err))
+	}
+
+	reader := bufio.NewReaderSize(f, charsetSampleSize)
+	sample, err := reader.Peek(charsetSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		f.Close()
+		return nil, errors.InternalError("FILE_READ_ERROR", fmt.Sprintf("Failed to read file: %v", err))
+	}
+
+	source, err := s.detectCharset(sample)
+	if err != nil {
+		f.Close()
+		return nil, errors.InternalError("INVALID_CONFIG", fmt.Sprintf("Invalid download.fallback_charset: %v", err))
+	}
+
+	return &TranscodedFile{
+		Reader:  transform.NewReader(reader, transform.Chain(source.NewDecoder(), target.NewEncoder())),
+		Closer:  f,
+		Charset: canonical,
+	}, nil
+}
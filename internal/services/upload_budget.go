@@ -0,0 +1,77 @@
+package services
+
+import (
+	"sync"
+
+	"storage-api/internal/config"
+	"storage-api/internal/utils"
+)
+
+// UploadBudget caps the total number of bytes being uploaded concurrently
+type UploadBudget struct {
+	mu         sync.Mutex
+	inFlight   int64
+	maxBytes   int64
+	retryAfter int
+}
+
+// NewUploadBudget creates a new upload byte budget from the upload configuration
+func NewUploadBudget() *UploadBudget {
+	uploadConfig := config.GetConfig().Storage.Upload
+
+	var maxBytes int64
+	if uploadConfig.MaxConcurrentBytes != "" {
+		if size, err := utils.ParseSizeString(uploadConfig.MaxConcurrentBytes); err == nil {
+			maxBytes = size
+		}
+	}
+
+	return &UploadBudget{
+		maxBytes:   maxBytes,
+		retryAfter: uploadConfig.RetryAfterSeconds,
+	}
+}
+
+// IsEnabled reports whether a concurrent byte budget is configured
+func (b *UploadBudget) IsEnabled() bool {
+	return b.maxBytes > 0
+}
+
+// Exceeds reports whether a single upload of the given size could never fit the budget
+func (b *UploadBudget) Exceeds(size int64) bool {
+	return b.IsEnabled() && size > b.maxBytes
+}
+
+// GetRetryAfter returns the number of seconds clients should wait before retrying a rejected upload
+func (b *UploadBudget) GetRetryAfter() int {
+	if b.retryAfter <= 0 {
+		return 5
+	}
+	return b.retryAfter
+}
+
+// Reserve claims size bytes of the budget.
+// It returns a release function that must be called once the upload finishes (successfully or not),
+// or false when the budget can't accommodate the upload right now.
+func (b *UploadBudget) Reserve(size int64) (func(), bool) {
+	if !b.IsEnabled() {
+		return func() {}, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.inFlight+size > b.maxBytes {
+		return nil, false
+	}
+	b.inFlight += size
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.inFlight -= size
+			b.mu.Unlock()
+		})
+	}, true
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+)
+
+const (
+	// uploadRateWindow is the window the per-IP upload rate is measured over
+	uploadRateWindow = time.Minute
+	// uploadIPSweepThreshold is the number of tracked addresses above which idle ones are swept
+	uploadIPSweepThreshold = 1024
+)
+
+// uploadIPState tracks the uploads of one client address
+type uploadIPState struct {
+	active int
+	starts []time.Time
+}
+
+// UploadIPLimiter caps concurrent uploads and the upload rate per client IP, so a single host
+// can't monopolize upload capacity
+type UploadIPLimiter struct {
+	mu            sync.Mutex
+	clients       map[string]*uploadIPState
+	maxConcurrent int
+	maxPerMinute  int
+	retryAfter    int
+}
+
+// NewUploadIPLimiter creates a new per-IP upload limiter from the upload configuration
+func NewUploadIPLimiter() *UploadIPLimiter {
+	uploadConfig := config.GetConfig().Storage.Upload
+	return &UploadIPLimiter{
+		clients:       make(map[string]*uploadIPState),
+		maxConcurrent: uploadConfig.PerIP.MaxConcurrent,
+		maxPerMinute:  uploadConfig.PerIP.MaxPerMinute,
+		retryAfter:    uploadConfig.RetryAfterSeconds,
+	}
+}
+
+// IsEnabled reports whether any per-IP limit is configured
+func (l *UploadIPLimiter) IsEnabled() bool {
+	return l.maxConcurrent > 0 || l.maxPerMinute > 0
+}
+
+// Acquire reserves an upload slot for the client address.
+// It returns a release function that must be called once the upload finishes, or false together
+// with the number of seconds to wait when the concurrency or rate limit is reached.
+func (l *UploadIPLimiter) Acquire(ip string) (func(), int, bool) {
+	if !l.IsEnabled() {
+		return func() {}, 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	state, ok := l.clients[ip]
+	if !ok {
+		if len(l.clients) >= uploadIPSweepThreshold {
+			for client := range l.clients {
+				l.forgetIdle(client, now)
+			}
+		}
+		state = &uploadIPState{}
+		l.clients[ip] = state
+	}
+	state.starts = pruneUploadStarts(state.starts, now)
+
+	if l.maxConcurrent > 0 && state.active >= l.maxConcurrent {
+		return nil, l.getRetryAfter(), false
+	}
+	if l.maxPerMinute > 0 && len(state.starts) >= l.maxPerMinute {
+		// A slot frees up once the oldest upload in the window falls out of it
+		wait := state.starts[0].Add(uploadRateWindow).Sub(now)
+		return nil, max(int(math.Ceil(wait.Seconds())), 1), false
+	}
+
+	state.active++
+	if l.maxPerMinute > 0 {
+		state.starts = append(state.starts, now)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			state.active--
+			l.forgetIdle(ip, time.Now())
+		})
+	}, 0, true
+}
+
+// getRetryAfter returns the number of seconds clients should wait after hitting the concurrency limit
+func (l *UploadIPLimiter) getRetryAfter() int {
+	if l.retryAfter <= 0 {
+		return 5
+	}
+	return l.retryAfter
+}
+
+// forgetIdle drops the state of an address with no active uploads and none left in the rate window
+func (l *UploadIPLimiter) forgetIdle(ip string, now time.Time) {
+	state, ok := l.clients[ip]
+	if !ok || state.active > 0 {
+		return
+	}
+	if state.starts = pruneUploadStarts(state.starts, now); len(state.starts) == 0 {
+		delete(l.clients, ip)
+	}
+}
+
+// pruneUploadStarts removes upload start times that have left the rate window
+func pruneUploadStarts(starts []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-uploadRateWindow)
+	i := 0
+	for i < len(starts) && !starts[i].After(cutoff) {
+		i++
+	}
+	return starts[i:]
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"storage-api/internal/config"
+)
+
+// UploadMaintenanceStatus describes whether uploads are paused for maintenance
+type UploadMaintenanceStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Message           string     `json:"message,omitempty"`
+	Since             *time.Time `json:"since,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"`
+}
+
+var (
+	uploadMaintenanceMutex sync.RWMutex
+	uploadMaintenance      *UploadMaintenanceStatus
+)
+
+// GetUploadMaintenance returns the current maintenance state, initialized from the configuration
+// until it is changed at runtime
+func GetUploadMaintenance() UploadMaintenanceStatus {
+	uploadMaintenanceMutex.RLock()
+	status := uploadMaintenance
+	uploadMaintenanceMutex.RUnlock()
+	if status != nil {
+		return *status
+	}
+
+	maintenanceConfig := config.GetConfig().Storage.Maintenance
+	return UploadMaintenanceStatus{
+		Enabled:           maintenanceConfig.PauseUploads,
+		Message:           maintenanceConfig.Message,
+		RetryAfterSeconds: getMaintenanceRetryAfter(maintenanceConfig.RetryAfterSeconds),
+	}
+}
+
+// SetUploadMaintenance pauses or resumes uploads. A zero retryAfter keeps the configured value.
+func SetUploadMaintenance(enabled bool, message string, retryAfter int) UploadMaintenanceStatus {
+	if retryAfter <= 0 {
+		retryAfter = getMaintenanceRetryAfter(config.GetConfig().Storage.Maintenance.RetryAfterSeconds)
+	}
+
+	status := UploadMaintenanceStatus{
+		Enabled:           enabled,
+		Message:           message,
+		RetryAfterSeconds: retryAfter,
+	}
+	if enabled {
+		now := time.Now().UTC()
+		status.Since = &now
+	}
+
+	uploadMaintenanceMutex.Lock()
+	// Keep the original start time when maintenance is re-enabled with a new message
+	if enabled && uploadMaintenance != nil && uploadMaintenance.Enabled {
+		status.Since = uploadMaintenance.Since
+	}
+	uploadMaintenance = &status
+	uploadMaintenanceMutex.Unlock()
+	return status
+}
+
+// getMaintenanceRetryAfter returns the Retry-After seconds sent while uploads are paused
+func getMaintenanceRetryAfter(seconds int) int {
+	if seconds <= 0 {
+		return 300
+	}
+	return seconds
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+)
+
+// UploadSource describes an incoming file independently of how it was transported
+type UploadSource struct {
+	Filename    string
+	Size        int64
+	ContentType string
+	open        func() (io.ReadCloser, error)
+}
+
+// Open opens the file content for reading
+func (u *UploadSource) Open() (io.ReadCloser, error) {
+	return u.open()
+}
+
+// NewUploadSourceFromHeader creates an upload source from a multipart file header
+func NewUploadSourceFromHeader(file *multipart.FileHeader) *UploadSource {
+	return &UploadSource{
+		Filename:    file.Filename,
+		Size:        file.Size,
+		ContentType: file.Header.Get("Content-Type"),
+		open: func() (io.ReadCloser, error) {
+			return file.Open()
+		},
+	}
+}
+
+// NewUploadSourcesFromHeaders creates upload sources from multipart file headers
+func NewUploadSourcesFromHeaders(files []*multipart.FileHeader) []*UploadSource {
+	sources := make([]*UploadSource, 0, len(files))
+	for _, file := range files {
+		sources = append(sources, NewUploadSourceFromHeader(file))
+	}
+	return sources
+}
+
+// NewUploadSourceFromBytes creates an upload source from an in-memory body
+func NewUploadSourceFromBytes(filename, contentType string, data []byte) *UploadSource {
+	return &UploadSource{
+		Filename:    filename,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+}
+
+// NewUploadSourceFromFile creates an upload source backed by a file on disk
+func NewUploadSourceFromFile(filename, contentType, path string, size int64) *UploadSource {
+	return &UploadSource{
+		Filename:    filename,
+		Size:        size,
+		ContentType: contentType,
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+}
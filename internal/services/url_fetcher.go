@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"storage-api/internal/config"
+	"storage-api/internal/utils"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+// FetchedFile is a remote file downloaded to a temporary location
+type FetchedFile struct {
+	Source  *UploadSource
+	tmpPath string
+}
+
+// Cleanup removes the temporary download
+func (f *FetchedFile) Cleanup() {
+	os.Remove(f.tmpPath)
+}
+
+// URLFetcher downloads remote files for upload-from-URL requests.
+// Connect time, total duration and size are all bounded so slow or oversized remotes can't tie up the server.
+type URLFetcher struct {
+	config config.URLFetchConfig
+}
+
+// NewURLFetcher creates a new URL fetcher instance
+func NewURLFetcher() *URLFetcher {
+	return &URLFetcher{
+		config: config.GetConfig().Storage.URLFetch,
+	}
+}
+
+// IsEnabled reports whether uploading from a URL is enabled
+func (f *URLFetcher) IsEnabled() bool {
+	return f.config.Enabled
+}
+
+// getMaxBytes returns the maximum number of bytes a fetch may transfer
+func (f *URLFetcher) getMaxBytes() (int64, error) {
+	if f.config.MaxSize == "" {
+		return 100 * 1024 * 1024, nil
+	}
+	return utils.ParseSizeString(f.config.MaxSize)
+}
+
+// newClient builds an HTTP client with the configured connect timeout
+func (f *URLFetcher) newClient() *http.Client {
+	connectTimeout := time.Duration(f.config.ConnectTimeoutSeconds) * time.Second
+	if connectTimeout <= 0 {
+		connectTimeout = 5 * time.Second
+	}
+
+	dialer := &net.Dialer{
+		Timeout: connectTimeout,
+		Control: f.checkDialAddress,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   connectTimeout,
+			ResponseHeaderTimeout: connectTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return nil
+		},
+	}
+}
+
+// nonGlobalNetworks lists special-purpose ranges that net.IP's classification methods don't
+// cover: shared carrier-grade NAT space, reserved, benchmarking and documentation ranges
+var nonGlobalNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001:db8::/32",
+)
+
+// mustParseCIDRs parses a fixed list of networks, panicking on an invalid entry
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isPublicAddress reports whether an address is globally routable, excluding private, loopback,
+// link-local, multicast and other special-purpose ranges
+func isPublicAddress(ip net.IP) bool {
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, network := range nonGlobalNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDialAddress refuses connections to non-public addresses unless explicitly allowed.
+// It runs after DNS resolution, so hostnames pointing at internal ranges are caught as well.
+func (f *URLFetcher) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if f.config.AllowPrivateNetworks {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddress(net.ParseIP(host)) {
+		return fmt.Errorf("connections to %s are not allowed", host)
+	}
+	return nil
+}
+
+// Fetch downloads a remote file to a temporary file.
+// The size cap is enforced while streaming since Content-Length may be missing or wrong;
+// partial downloads are removed when the fetch fails or times out.
+func (f *URLFetcher) Fetch(rawURL, fileName string) (*FetchedFile, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, errors.BadRequestError("INVALID_URL", "URL must be an absolute http or https URL")
+	}
+
+	maxBytes, err := f.getMaxBytes()
+	if err != nil {
+		return nil, errors.InternalError("INVALID_CONFIG", fmt.Sprintf("Invalid url_fetch.max_size: %v", err))
+	}
+
+	timeout := time.Duration(f.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedURL.String(), nil)
+	if err != nil {
+		return nil, errors.BadRequestError("INVALID_URL", fmt.Sprintf("Invalid URL: %v", err))
+	}
+
+	resp, err := f.newClient().Do(req)
+	if err != nil {
+		return nil, f.fetchError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.BadRequestError("URL_FETCH_FAILED", fmt.Sprintf("Remote server responded with %s", resp.Status))
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, errors.BadRequestError("FILE_TOO_LARGE", fmt.Sprintf("Remote file exceeds the maximum of %s", f.config.MaxSize))
+	}
+
+	tmpFile, err := os.CreateTemp("", "storage-fetch-*")
+	if err != nil {
+		return nil, errors.InternalError("FILE_CREATION_ERROR", fmt.Sprintf("Failed to create temporary file: %v", err))
+	}
+	fetched := &FetchedFile{tmpPath: tmpFile.Name()}
+
+	// Read one byte past the limit to detect bodies larger than advertised
+	written, err := io.Copy(tmpFile, io.LimitReader(resp.Body, maxBytes+1))
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fetched.Cleanup()
+		return nil, f.fetchError(ctx, err)
+	}
+	if written > maxBytes {
+		fetched.Cleanup()
+		return nil, errors.BadRequestError("FILE_TOO_LARGE", fmt.Sprintf("Remote file exceeds the maximum of %s", f.config.MaxSize))
+	}
+
+	if fileName == "" {
+		fileName = remoteFileName(resp)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	fetched.Source = NewUploadSourceFromFile(fileName, contentType, fetched.tmpPath, written)
+
+	return fetched, nil
+}
+
+// fetchError converts a transfer error, reporting timeouts distinctly
+func (f *URLFetcher) fetchError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.TimeoutError("URL_FETCH_TIMEOUT", "Fetching the remote file took too long")
+	}
+	return errors.BadRequestError("URL_FETCH_FAILED", fmt.Sprintf("Failed to fetch remote file: %v", err))
+}
+
+// remoteFileName derives a file name from Content-Disposition or the final URL path
+func remoteFileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "" && name != "." && name != "/" {
+			return name
+		}
+	}
+
+	if name := path.Base(resp.Request.URL.Path); name != "" && name != "." && name != "/" {
+		return name
+	}
+	return "download"
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"storage-api/internal/config"
+
+	"github.com/kerimovok/go-pkg-utils/errors"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"192.0.0.8", false},
+		{"192.0.2.1", false},
+		{"198.18.0.1", false},
+		{"203.0.113.7", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"2001:db8::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:100.64.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := isPublicAddress(net.ParseIP(tt.address)); got != tt.want {
+				t.Errorf("isPublicAddress(%s) = %v, want %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRefusesPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	f := &URLFetcher{config: config.URLFetchConfig{}}
+	fetched, err := f.Fetch(server.URL+"/secret.txt", "")
+	if err == nil {
+		fetched.Cleanup()
+		t.Fatal("Fetch() of a loopback address succeeded")
+	}
+	if !errors.IsCode(err, "URL_FETCH_FAILED") {
+		t.Errorf("Fetch() error = %v, want URL_FETCH_FAILED", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	f := &URLFetcher{config: config.URLFetchConfig{AllowPrivateNetworks: true, MaxSize: "1KB"}}
+	fetched, err := f.Fetch(server.URL+"/docs/hello.txt", "")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer fetched.Cleanup()
+
+	if fetched.Source.Filename != "hello.txt" || fetched.Source.Size != 5 || fetched.Source.ContentType != "text/plain" {
+		t.Errorf("Fetch() = %s (%d bytes, %s), want hello.txt (5 bytes, text/plain)",
+			fetched.Source.Filename, fetched.Source.Size, fetched.Source.ContentType)
+	}
+}
+
+func TestFetchSizeCap(t *testing.T) {
+	body := strings.Repeat("x", 2048)
+	tests := []struct {
+		name          string
+		contentLength bool
+	}{
+		{"declared length", true},
+		{"chunked", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			f := &URLFetcher{config: config.URLFetchConfig{AllowPrivateNetworks: true, MaxSize: "1KB"}}
+			fetched, err := f.Fetch(server.URL+"/large.bin", "")
+			if err == nil {
+				fetched.Cleanup()
+				t.Fatal("Fetch() of a file over the size cap succeeded")
+			}
+			if !errors.IsCode(err, "FILE_TOO_LARGE") {
+				t.Errorf("Fetch() error = %v, want FILE_TOO_LARGE", err)
+			}
+		})
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send headers and part of the body, then stall until the client gives up
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	f := &URLFetcher{config: config.URLFetchConfig{AllowPrivateNetworks: true, TimeoutSeconds: 1}}
+	fetched, err := f.Fetch(server.URL+"/slow.bin", "")
+	if err == nil {
+		fetched.Cleanup()
+		t.Fatal("Fetch() of a stalled transfer succeeded")
+	}
+	if !errors.IsCode(err, "URL_FETCH_TIMEOUT") {
+		t.Errorf("Fetch() error = %v, want URL_FETCH_TIMEOUT", err)
+	}
+}
@@ -11,9 +11,16 @@ import (
 // Common utilities used across the storage-api
 
 // GetFileExtension extracts and normalizes the file extension
+// Trailing dots and spaces are ignored, as Windows drops them when saving the file, so
+// "report.PDF. " has the extension "pdf".
 func GetFileExtension(filename string) string {
-	ext := strings.ToLower(filepath.Ext(filename))
-	return strings.TrimPrefix(ext, ".")
+	return NormalizeExtension(filepath.Ext(strings.TrimRight(filename, ". ")))
+}
+
+// NormalizeExtension returns the canonical form of an extension: lowercase and without a leading
+// dot. Stored extensions, file types and validation rules all use this form.
+func NormalizeExtension(ext string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(ext), "."))
 }
 
 // GetFileExtensionFromHeader extracts extension from multipart file header
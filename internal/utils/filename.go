@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillicTransliterations maps Cyrillic letters to their Latin equivalents
+var cyrillicTransliterations = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "yo", 'є': "ye",
+	'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh",
+	'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ә': "a", 'ғ': "g", 'қ': "q", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u", 'һ': "h",
+	'ј': "j", 'ҹ': "c",
+}
+
+// TransliterateFilename converts a filename to a filesystem- and header-safe ASCII form.
+// Accents are folded via Unicode decomposition, Cyrillic is transliterated, and any remaining
+// unsupported characters are replaced with underscores. If nothing usable remains (e.g. a purely
+// CJK name), a short deterministic hash of the original base name is used instead.
+func TransliterateFilename(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	safeBase := transliterateToASCII(base)
+	safeExt := transliterateToASCII(strings.TrimPrefix(ext, "."))
+
+	if strings.Trim(safeBase, "_-.") == "" {
+		safeBase = fmt.Sprintf("file-%x", sha1.Sum([]byte(base)))[:13]
+	}
+
+	if safeExt == "" {
+		return safeBase
+	}
+	return safeBase + "." + safeExt
+}
+
+// NormalizeFilenameNFC returns the NFC form of a filename and whether it differed from the input
+func NormalizeFilenameNFC(name string) (string, bool) {
+	normalized := norm.NFC.String(name)
+	return normalized, normalized != name
+}
+
+// transliterateToASCII folds a string to ASCII letters, digits, dots, dashes and underscores
+func transliterateToASCII(value string) string {
+	var builder strings.Builder
+	lastUnderscore := false
+
+	// Transliterate composed characters first so letters like "й" aren't reduced to their base form
+	for _, composed := range norm.NFC.String(value) {
+		if replacement, ok := cyrillicTransliterations[unicode.ToLower(composed)]; ok {
+			if unicode.IsUpper(composed) && replacement != "" {
+				replacement = strings.ToUpper(replacement[:1]) + replacement[1:]
+			}
+			builder.WriteString(replacement)
+			lastUnderscore = false
+			continue
+		}
+
+		for _, r := range norm.NFKD.String(string(composed)) {
+			// Drop combining marks left over from decomposition (accents, diacritics)
+			if unicode.Is(unicode.Mn, r) {
+				continue
+			}
+
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+				builder.WriteRune(r)
+				lastUnderscore = r == '_'
+				continue
+			}
+
+			// Collapse runs of unsupported characters into a single underscore
+			if !lastUnderscore {
+				builder.WriteRune('_')
+				lastUnderscore = true
+			}
+		}
+	}
+
+	return strings.Trim(builder.String(), "_")
+}
+
+// ContentDisposition builds a Content-Disposition header value for a file name. The filename
+// parameter carries an ASCII form every client understands; when the name isn't plain ASCII and
+// encodeUTF8 is set, the exact name follows as an RFC 5987 filename* parameter for clients that
+// support it.
+func ContentDisposition(disposition, name string, encodeUTF8 bool) string {
+	fallback := name
+	if !isPrintableASCII(name) {
+		fallback = TransliterateFilename(name)
+	}
+	fallback = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fallback)
+
+	value := disposition + `; filename="` + fallback + `"`
+	if encodeUTF8 && fallback != name && utf8.ValidString(name) {
+		value += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+	return value
+}
+
+// isPrintableASCII reports whether s consists only of printable ASCII characters
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeRFC5987 percent-encodes the UTF-8 bytes of s, leaving only RFC 5987 attr-chars as they are
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b < utf8.RuneSelf && (unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)) || strings.IndexByte("!#$&+-.^_`|~", b) >= 0) {
+			builder.WriteByte(b)
+			continue
+		}
+		builder.WriteByte('%')
+		builder.WriteByte(hex[b>>4])
+		builder.WriteByte(hex[b&0x0F])
+	}
+	return builder.String()
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name        string
+		disposition string
+		fileName    string
+		encodeUTF8  bool
+		want        string
+	}{
+		{"ascii", "attachment", "report.pdf", true, `attachment; filename="report.pdf"`},
+		{"inline", "inline", "photo.jpg", true, `inline; filename="photo.jpg"`},
+		{"quotes escaped", "attachment", `say "hi".txt`, true, `attachment; filename="say \"hi\".txt"; filename*=UTF-8''say%20%22hi%22.txt`},
+		{"backslash escaped", "attachment", `a\b.txt`, false, `attachment; filename="a\\b.txt"`},
+		{"utf8 encoded", "attachment", "résumé.pdf", true, `attachment; filename="resume.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`},
+		{"utf8 ascii only", "attachment", "résumé.pdf", false, `attachment; filename="resume.pdf"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentDisposition(tt.disposition, tt.fileName, tt.encodeUTF8); got != tt.want {
+				t.Errorf("ContentDisposition(%q, %q, %v) = %s, want %s", tt.disposition, tt.fileName, tt.encodeUTF8, got, tt.want)
+			}
+		})
+	}
+}
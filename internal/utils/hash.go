@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// SupportedHashAlgorithms lists the content hash algorithms the service can compute
+var SupportedHashAlgorithms = []string{"md5", "sha1", "sha256", "sha512"}
+
+// NormalizeHashAlgorithm lowercases an algorithm name and strips dashes (e.g. "SHA-256" -> "sha256")
+func NormalizeHashAlgorithm(algorithm string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(algorithm)), "-", "")
+}
+
+// IsSupportedHashAlgorithm reports whether the algorithm can be computed
+func IsSupportedHashAlgorithm(algorithm string) bool {
+	_, err := NewHasher(algorithm)
+	return err == nil
+}
+
+// IsHexString reports whether s is a non-empty string of hexadecimal digits
+func IsHexString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewHasher returns a new hash.Hash for the given algorithm name
+func NewHasher(algorithm string) (hash.Hash, error) {
+	switch NormalizeHashAlgorithm(algorithm) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+}
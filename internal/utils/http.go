@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+)
+
+// ETagMatches reports whether an If-Match/If-None-Match header value matches the given entity tag.
+// The comparison ignores weak validator prefixes, which is correct for If-None-Match and
+// conservative for If-Match since only strong tags are issued for content.
+func ETagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AcceptsEncoding reports whether an Accept-Encoding header allows the given content coding.
+// Codings listed with q=0 are treated as refused; a "*" entry accepts any coding not listed explicitly.
+func AcceptsEncoding(header, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name != coding && name != "*" {
+			continue
+		}
+
+		refused := false
+		for _, param := range fields[1:] {
+			param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+			if param == "q=0" || strings.HasPrefix(param, "q=0.") && strings.Trim(param[4:], "0") == "" {
+				refused = true
+			}
+		}
+
+		// An explicit entry for the coding overrides the wildcard
+		if name == coding {
+			return !refused
+		}
+		accepted = !refused
+	}
+	return accepted
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		want   bool
+	}{
+		{"empty header", "", `"abc"`, false},
+		{"wildcard", "*", `"abc"`, true},
+		{"exact", `"abc"`, `"abc"`, true},
+		{"different", `"abd"`, `"abc"`, false},
+		{"list", `"x", "abc" , "y"`, `"abc"`, true},
+		{"weak header", `W/"abc"`, `"abc"`, true},
+		{"weak etag", `"abc"`, `W/"abc"`, true},
+		{"unquoted", `abc`, `"abc"`, false},
+		{"whitespace", `  "abc"  `, `"abc"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.header, tt.etag); got != tt.want {
+				t.Errorf("ETagMatches(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"empty", "", false},
+		{"listed", "gzip", true},
+		{"listed among others", "br, gzip, deflate", true},
+		{"case insensitive", "GZIP", true},
+		{"not listed", "br, deflate", false},
+		{"weighted", "gzip;q=0.5", true},
+		{"refused", "gzip;q=0", false},
+		{"refused with decimals", "gzip; q=0.000", false},
+		{"low but accepted", "gzip;q=0.001", true},
+		{"wildcard", "*", true},
+		{"wildcard refused", "*;q=0", false},
+		{"explicit overrides wildcard", "gzip;q=0, *", false},
+		{"explicit accepts over refused wildcard", "*;q=0, gzip", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptsEncoding(tt.header, "gzip"); got != tt.want {
+				t.Errorf("AcceptsEncoding(%q, gzip) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record ID strategies. Every strategy produces a 128-bit value stored in the uuid ID column.
+const (
+	// IDStrategyUUID leaves the ID to the database default (random UUIDv4)
+	IDStrategyUUID = "uuid"
+	// IDStrategyUUIDv7 generates time-ordered UUIDv7 IDs
+	IDStrategyUUIDv7 = "uuidv7"
+	// IDStrategyULID generates ULIDs: a millisecond timestamp followed by 80 random bits
+	IDStrategyULID = "ulid"
+)
+
+// crockfordAlphabet is the base32 alphabet used for the text form of ULIDs
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// IsSupportedIDStrategy reports whether the ID strategy is known; empty selects the default
+func IsSupportedIDStrategy(strategy string) bool {
+	switch strings.ToLower(strategy) {
+	case "", IDStrategyUUID, IDStrategyUUIDv7, IDStrategyULID:
+		return true
+	}
+	return false
+}
+
+// GenerateID returns a new record ID for the strategy. uuid.Nil means the database assigns the ID.
+func GenerateID(strategy string) (uuid.UUID, error) {
+	switch strings.ToLower(strategy) {
+	case "", IDStrategyUUID:
+		return uuid.Nil, nil
+	case IDStrategyUUIDv7:
+		return uuid.NewV7()
+	case IDStrategyULID:
+		return newULID(time.Now())
+	}
+	return uuid.Nil, fmt.Errorf("unsupported ID strategy: %s", strategy)
+}
+
+// newULID builds a ULID from the timestamp and random bits
+func newULID(t time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(t.UnixMilli()))
+	copy(id[:6], timestamp[2:])
+	if _, err := rand.Read(id[6:]); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// ParseID parses a record ID given either in UUID form or as a 26-character ULID.
+// Responses always carry IDs in UUID form; the ULID text form is accepted on input only.
+func ParseID(s string) (uuid.UUID, error) {
+	if len(s) == 26 {
+		return parseULID(s)
+	}
+	return uuid.Parse(s)
+}
+
+// parseULID decodes the Crockford base32 text form of a ULID
+func parseULID(s string) (uuid.UUID, error) {
+	var id uuid.UUID
+	upper := strings.ToUpper(s)
+	if upper[0] > '7' {
+		return uuid.Nil, fmt.Errorf("invalid ULID: %s", s)
+	}
+
+	var bits, value uint
+	n := len(id) - 1
+	for i := len(upper) - 1; i >= 0; i-- {
+		digit := strings.IndexByte(crockfordAlphabet, upper[i])
+		if digit < 0 {
+			return uuid.Nil, fmt.Errorf("invalid ULID: %s", s)
+		}
+		value |= uint(digit) << bits
+		bits += 5
+		if bits >= 8 && n >= 0 {
+			id[n] = byte(value)
+			value >>= 8
+			bits -= 8
+			n--
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"uuid", "01563e3a-b5d3-d676-4c61-efb99302bd5b", "01563e3a-b5d3-d676-4c61-efb99302bd5b", false},
+		{"ulid", "01ARZ3NDEKTSV4RRFFQ69G5FAV", "01563e3a-b5d3-d676-4c61-efb99302bd5b", false},
+		{"lowercase ulid", "01arz3ndektsv4rrffq69g5fav", "01563e3a-b5d3-d676-4c61-efb99302bd5b", false},
+		{"largest ulid", "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", "ffffffff-ffff-ffff-ffff-ffffffffffff", false},
+		{"ulid overflow", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "", true},
+		{"ulid invalid character", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "", true},
+		{"empty", "", "", true},
+		{"garbage", "not-an-id", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseID(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseID(%q) = %s, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseID(%q) returned error: %v", tt.input, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseID(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateID(t *testing.T) {
+	if id, err := GenerateID(IDStrategyUUID); err != nil || id != uuid.Nil {
+		t.Errorf("GenerateID(uuid) = %s, %v; want the database to assign the ID", id, err)
+	}
+
+	if id, err := GenerateID(IDStrategyUUIDv7); err != nil || id.Version() != 7 {
+		t.Errorf("GenerateID(uuidv7) = %s, %v; want a version 7 UUID", id, err)
+	}
+
+	if _, err := GenerateID("ksuid"); err == nil {
+		t.Error("GenerateID(ksuid) succeeded, want an unsupported strategy error")
+	}
+}
+
+func TestNewULIDTimestamp(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	id, err := newULID(now)
+	if err != nil {
+		t.Fatalf("newULID returned error: %v", err)
+	}
+
+	// The first 48 bits carry the millisecond timestamp
+	var millis int64
+	for _, b := range id[:6] {
+		millis = millis<<8 | int64(b)
+	}
+	if millis != now.UnixMilli() {
+		t.Errorf("ULID timestamp = %d, want %d", millis, now.UnixMilli())
+	}
+
+	// Later ULIDs sort after earlier ones
+	later, err := newULID(now.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("newULID returned error: %v", err)
+	}
+	if later.String() <= id.String() {
+		t.Errorf("ULID %s generated later does not sort after %s", later, id)
+	}
+}
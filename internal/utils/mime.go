@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// offsetSignature identifies a format by magic bytes found at a fixed offset
+type offsetSignature struct {
+	offset      int
+	magic       []byte
+	contentType string
+}
+
+// offsetSignatures lists formats whose magic bytes sit at an offset http.DetectContentType doesn't
+// recognize, some of them beyond the 512 bytes it inspects
+var offsetSignatures = []offsetSignature{
+	{offset: 128, magic: []byte("DICM"), contentType: "application/dicom"},
+	{offset: 257, magic: []byte("ustar"), contentType: "application/x-tar"},
+	{offset: 32769, magic: []byte("CD001"), contentType: "application/x-iso9660-image"},
+}
+
+// DetectContentType sniffs the content type of data. It extends http.DetectContentType with
+// signatures located further into the file, which only match when enough data was read.
+func DetectContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if contentType != "application/octet-stream" {
+		return contentType
+	}
+
+	for _, signature := range offsetSignatures {
+		end := signature.offset + len(signature.magic)
+		if len(data) >= end && bytes.Equal(data[signature.offset:end], signature.magic) {
+			return signature.contentType
+		}
+	}
+	return contentType
+}
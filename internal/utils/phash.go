@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math/bits"
+	"strconv"
+
+	// Register decoders for the image formats supported by the standard library
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ComputeDifferenceHash computes a 64-bit difference hash (dHash) of an image.
+// The image is reduced to a 9x8 grayscale grid and each bit records whether a
+// cell is brighter than its right-hand neighbour, so visually similar images
+// produce hashes with a small Hamming distance.
+func ComputeDifferenceHash(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	const width, height = 9, 8
+	grid := reduceToGrayscale(img, width, height)
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// reduceToGrayscale downsamples an image to the given size by averaging the luminance of each cell
+func reduceToGrayscale(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	grid := make([][]float64, height)
+	for y := 0; y < height; y++ {
+		grid[y] = make([]float64, width)
+
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var sum float64
+			var count int
+			for py := y0; py < y1 && py < bounds.Max.Y; py++ {
+				for px := x0; px < x1 && px < bounds.Max.X; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				grid[y][x] = sum / float64(count)
+			}
+		}
+	}
+
+	return grid
+}
+
+// FormatPerceptualHash formats a perceptual hash as a fixed-width hex string
+func FormatPerceptualHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParsePerceptualHash parses a hex-encoded perceptual hash
+func ParsePerceptualHash(hash string) (uint64, error) {
+	return strconv.ParseUint(hash, 16, 64)
+}
+
+// HammingDistance returns the number of differing bits between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
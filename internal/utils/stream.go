@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"io"
+	"sync"
+)
+
+// ReleasingReadCloser wraps a ReadCloser and invokes a release callback once when closed
+type ReleasingReadCloser struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// NewReleasingReadCloser returns a ReadCloser that calls release after the underlying reader is closed
+func NewReleasingReadCloser(rc io.ReadCloser, release func()) *ReleasingReadCloser {
+	return &ReleasingReadCloser{ReadCloser: rc, release: release}
+}
+
+// Close closes the underlying reader and releases the associated resource
+func (r *ReleasingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -9,7 +9,11 @@ import (
 	"storage-api/internal/constants"
 	"storage-api/internal/database"
 	"storage-api/internal/routes"
+	"storage-api/internal/services"
+	"storage-api/internal/utils"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -20,6 +24,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/google/uuid"
 	pkgConfig "github.com/kerimovok/go-pkg-utils/config"
+	"github.com/kerimovok/go-pkg-utils/httpx"
 	pkgValidator "github.com/kerimovok/go-pkg-utils/validator"
 )
 
@@ -34,21 +39,55 @@ func init() {
 		log.Fatalf("configuration validation failed: %v", err)
 	}
 
-	// Connect to database
-	if err := database.ConnectDB(); err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+	// Reject an unknown ID strategy before any record is written
+	if strategy := config.GetConfig().Storage.IDs.Strategy; !utils.IsSupportedIDStrategy(strategy) {
+		log.Fatalf("unsupported ID strategy: %s", strategy)
+	}
+
+	// Connect to database, riding out brief outages at boot
+	startupConfig := config.GetConfig().Storage.DatabaseStartup
+	retryPolicy := database.RetryPolicy{
+		MaxAttempts: startupConfig.MaxAttempts,
+		BaseDelay:   time.Duration(startupConfig.RetryDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(startupConfig.MaxRetryDelayMs) * time.Millisecond,
+		MaxDuration: time.Duration(startupConfig.MaxDurationSeconds) * time.Second,
+	}
+	if err := database.ConnectWithRetry(retryPolicy); err != nil {
+		if !startupConfig.StartDegraded {
+			log.Fatalf("failed to connect to database: %v", err)
+		}
+		log.Printf("Warning: Database unavailable, starting in degraded mode: %v", err)
+		database.ConnectInBackground(retryPolicy)
 	}
 }
 
 func setupApp() *fiber.App {
-	app := fiber.New(fiber.Config{
+	// The proxy header is only honored for requests coming from a trusted proxy
+	clientConfig := config.GetConfig().Storage.Clients
+	fiberConfig := fiber.Config{
 		BodyLimit: 100 * 1024 * 1024, // 100MB limit for file uploads
-	})
+		// Uploads read the body as it arrives instead of after it is buffered in full
+		StreamRequestBody: true,
+	}
+	if len(clientConfig.TrustedProxies) > 0 {
+		fiberConfig.ProxyHeader = clientConfig.ProxyHeader
+		if fiberConfig.ProxyHeader == "" {
+			fiberConfig.ProxyHeader = fiber.HeaderXForwardedFor
+		}
+		fiberConfig.EnableTrustedProxyCheck = true
+		fiberConfig.TrustedProxies = clientConfig.TrustedProxies
+		fiberConfig.EnableIPValidation = true
+	}
+	app := fiber.New(fiberConfig)
 
 	// Middleware
 	app.Use(helmet.New())
 	app.Use(cors.New())
-	app.Use(compress.New())
+	app.Use(compress.New(compress.Config{
+		// Downloads carry a strong ETag, Digest and Content-MD5 describing the stored bytes, so they
+		// are never encoded on the fly; precompressed gzip variants are served where enabled
+		Next: isContentDownload,
+	}))
 	app.Use(healthcheck.New())
 	app.Use(requestid.New(requestid.Config{
 		Generator: func() string {
@@ -57,9 +96,32 @@ func setupApp() *fiber.App {
 	}))
 	app.Use(logger.New())
 
+	// In degraded mode API requests are refused until the database connection is established
+	app.Use(func(c *fiber.Ctx) error {
+		if !database.IsConnected() && strings.HasPrefix(c.Path(), "/api/") {
+			response := httpx.ServiceUnavailable("Database unavailable, please retry later")
+			return httpx.SendResponse(c, response)
+		}
+		return c.Next()
+	})
+
 	return app
 }
 
+// isContentDownload reports whether a request downloads a file's stored content: the raw and
+// original routes, or a file requested with ?download or ?inline
+func isContentDownload(c *fiber.Ctx) bool {
+	if strings.HasSuffix(c.Path(), "/raw") || strings.HasSuffix(c.Path(), "/original") {
+		return true
+	}
+	for _, param := range []string{"download", "inline"} {
+		if value := c.Query(param); value == "true" || value == "1" {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
 	// Setup Fiber app
 	app := setupApp()
@@ -67,6 +129,17 @@ func main() {
 	// Setup routes
 	routes.SetupRoutes(app)
 
+	// Background work needs the database, so it waits for the connection in degraded mode
+	database.WhenConnected(func() {
+		// Resume background work interrupted by a previous shutdown
+		services.NewRehashService().ResumePending()
+		services.NewScanService().ResumePending()
+
+		// Start periodic background jobs
+		services.NewIntegrityService().StartSchedule()
+		services.NewDeletionService().StartSchedule()
+	})
+
 	// Setup graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)